@@ -0,0 +1,418 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) listCollectionsHandler(resp http.ResponseWriter, req *http.Request) {
+	collections, err := app.models.Collections.GetAllForUser(req.Context(), app.contextGetUser(req).ID)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"collections": collections}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) createCollectionHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Visibility  string `json:"visibility"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	collection := &data.Collection{
+		UserID:      app.contextGetUser(req).ID,
+		Name:        input.Name,
+		Description: input.Description,
+		Visibility:  input.Visibility,
+	}
+	if collection.Visibility == "" {
+		collection.Visibility = data.VisibilityPrivate
+	}
+
+	v := validator.New()
+	if data.ValidateCollection(v, collection); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Collections.Insert(req.Context(), collection)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/users/me/collections/%d", collection.ID))
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"collection": collection}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showCollectionHandler(resp http.ResponseWriter, req *http.Request) {
+	collection, ok := app.readCollection(resp, req)
+	if !ok {
+		return
+	}
+
+	app.writeCollection(resp, req, collection)
+}
+
+func (app *application) updateCollectionHandler(resp http.ResponseWriter, req *http.Request) {
+	collection, ok := app.readCollection(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Visibility  *string `json:"visibility"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.Name != nil {
+		collection.Name = *input.Name
+	}
+	if input.Description != nil {
+		collection.Description = *input.Description
+	}
+	if input.Visibility != nil {
+		collection.Visibility = *input.Visibility
+	}
+
+	v := validator.New()
+	if data.ValidateCollection(v, collection); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Collections.Update(req.Context(), collection)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"collection": collection}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) deleteCollectionHandler(resp http.ResponseWriter, req *http.Request) {
+	collection, ok := app.readCollection(resp, req)
+	if !ok {
+		return
+	}
+
+	err := app.models.Collections.Delete(req.Context(), collection.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "collection successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) addCollectionItemHandler(resp http.ResponseWriter, req *http.Request) {
+	collection, ok := app.readCollection(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MovieID  int64  `json:"movie_id"`
+		Position int    `json:"position"`
+		Note     string `json:"note"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	item := &data.CollectionItem{
+		CollectionID: collection.ID,
+		MovieID:      input.MovieID,
+		Position:     input.Position,
+		Note:         input.Note,
+	}
+
+	v := validator.New()
+	if data.ValidateCollectionItem(v, item); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movies.Get(req.Context(), item.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("movie_id", "must be an existing movie")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.models.Collections.AddItem(req.Context(), item)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCollectionItem):
+			v.AddError("movie_id", "is already in this collection")
+			app.failedValidationResponse(resp, req, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/users/me/collections/%d/items/%d", collection.ID, item.MovieID))
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"item": item}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showCollectionItemHandler(resp http.ResponseWriter, req *http.Request) {
+	item, ok := app.readCollectionItem(resp, req)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(resp, http.StatusOK, envelope{"item": item}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) updateCollectionItemHandler(resp http.ResponseWriter, req *http.Request) {
+	item, ok := app.readCollectionItem(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Position *int    `json:"position"`
+		Note     *string `json:"note"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.Position != nil {
+		item.Position = *input.Position
+	}
+	if input.Note != nil {
+		item.Note = *input.Note
+	}
+
+	v := validator.New()
+	if data.ValidateCollectionItem(v, item); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Collections.UpdateItem(req.Context(), item)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"item": item}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) removeCollectionItemHandler(resp http.ResponseWriter, req *http.Request) {
+	item, ok := app.readCollectionItem(resp, req)
+	if !ok {
+		return
+	}
+
+	err := app.models.Collections.RemoveItem(req.Context(), item.CollectionID, item.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "item successfully removed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) listPublicCollectionsHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	input.Filters.SortSafeList = []string{"created_at", "name", "-created_at", "-name"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	collections, metadata, err := app.models.Collections.GetAllPublic(req.Context(), input.Filters)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"collections": collections, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// showSharedCollectionHandler shows a collection by its share id, which is
+// the only id exposed to other users. Private collections are only visible
+// to their owner.
+func (app *application) showSharedCollectionHandler(resp http.ResponseWriter, req *http.Request) {
+	collection, err := app.models.Collections.GetByShareID(req.Context(), app.readShareIDParam(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	if collection.Visibility == data.VisibilityPrivate && collection.UserID != app.contextGetUser(req).ID {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	app.writeCollection(resp, req, collection)
+}
+
+func (app *application) writeCollection(resp http.ResponseWriter, req *http.Request, collection *data.Collection) {
+	items, err := app.models.Collections.GetItems(req.Context(), collection.ID)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+	collection.Items = items
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"collection": collection}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// readCollection loads the collection in the URL, writing a not found
+// response unless it belongs to the authenticated user.
+func (app *application) readCollection(resp http.ResponseWriter, req *http.Request) (*data.Collection, bool) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return nil, false
+	}
+
+	collection, err := app.models.Collections.Get(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return nil, false
+	}
+
+	if collection.UserID != app.contextGetUser(req).ID {
+		app.notFoundErrorRespone(resp, req)
+		return nil, false
+	}
+
+	return collection, true
+}
+
+func (app *application) readCollectionItem(resp http.ResponseWriter, req *http.Request) (*data.CollectionItem, bool) {
+	collection, ok := app.readCollection(resp, req)
+	if !ok {
+		return nil, false
+	}
+
+	movieID, err := app.readMovieIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return nil, false
+	}
+
+	item, err := app.models.Collections.GetItem(req.Context(), collection.ID, movieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return nil, false
+	}
+
+	return item, true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestCollectionItemOrdering(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, token := insertTestUser(t, app, "alice@example.com")
+
+	var movies []int64
+	for _, title := range []string{"Moana", "Up", "Coco", "Soul"} {
+		movies = append(movies, insertTestMovie(t, app, title).ID)
+	}
+
+	status, _, body := ts.do(t, http.MethodPost, "/v1/users/me/collections", token, `{"name": "Favourites"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d creating collection; want %d: %s", status, http.StatusCreated, body)
+	}
+
+	var created struct {
+		Collection struct {
+			ID int64 `json:"id"`
+		} `json:"collection"`
+	}
+	decodeTestJSON(t, body, &created)
+
+	path := fmt.Sprintf("/v1/users/me/collections/%d", created.Collection.ID)
+
+	order := func() []int64 {
+		t.Helper()
+
+		status, _, body := ts.do(t, http.MethodGet, path, token, "")
+		if status != http.StatusOK {
+			t.Fatalf("got status %d showing collection; want %d: %s", status, http.StatusOK, body)
+		}
+
+		var got struct {
+			Collection struct {
+				Items []struct {
+					MovieID  int64 `json:"movie_id"`
+					Position int   `json:"position"`
+				} `json:"items"`
+			} `json:"collection"`
+		}
+		decodeTestJSON(t, body, &got)
+
+		var ids []int64
+		for i, item := range got.Collection.Items {
+			if item.Position != i+1 {
+				t.Errorf("got position %d for item %d; want %d", item.Position, i, i+1)
+			}
+			ids = append(ids, item.MovieID)
+		}
+		return ids
+	}
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   []int64
+	}{
+		{"append", http.MethodPost, "/items", fmt.Sprintf(`{"movie_id": %d}`, movies[0]), []int64{movies[0]}},
+		{"append again", http.MethodPost, "/items", fmt.Sprintf(`{"movie_id": %d}`, movies[1]), []int64{movies[0], movies[1]}},
+		{"insert at front", http.MethodPost, "/items", fmt.Sprintf(`{"movie_id": %d, "position": 1}`, movies[2]), []int64{movies[2], movies[0], movies[1]}},
+		{"insert in middle", http.MethodPost, "/items", fmt.Sprintf(`{"movie_id": %d, "position": 2}`, movies[3]), []int64{movies[2], movies[3], movies[0], movies[1]}},
+		{"move down", http.MethodPatch, fmt.Sprintf("/items/%d", movies[2]), `{"position": 3}`, []int64{movies[3], movies[0], movies[2], movies[1]}},
+		{"move up", http.MethodPatch, fmt.Sprintf("/items/%d", movies[1]), `{"position": 1}`, []int64{movies[1], movies[3], movies[0], movies[2]}},
+		{"move past end", http.MethodPatch, fmt.Sprintf("/items/%d", movies[3]), `{"position": 99}`, []int64{movies[1], movies[0], movies[2], movies[3]}},
+		{"remove", http.MethodDelete, fmt.Sprintf("/items/%d", movies[0]), ``, []int64{movies[1], movies[2], movies[3]}},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			status, _, body := ts.do(t, step.method, path+step.path, token, step.body)
+			if status >= http.StatusBadRequest {
+				t.Fatalf("got status %d: %s", status, body)
+			}
+
+			if got := order(); !slices.Equal(got, step.want) {
+				t.Errorf("got order %v; want %v", got, step.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) listMovieCreditsHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	credits, err := app.models.Credits.GetAllForMovie(req.Context(), movieID)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"credits": credits}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) createMovieCreditHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PersonID  int64  `json:"person_id"`
+		Role      string `json:"role"`
+		Character string `json:"character"`
+		Billing   int32  `json:"billing"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	credit := &data.Credit{
+		MovieID:   movieID,
+		PersonID:  input.PersonID,
+		Role:      input.Role,
+		Character: input.Character,
+		Billing:   input.Billing,
+	}
+
+	v := validator.New()
+	if data.ValidateCredit(v, credit); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Credits.Insert(req.Context(), credit)
+	if err != nil {
+		app.creditErrorResponse(resp, req, v, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%d/credits/%d", movieID, credit.ID))
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"credit": credit}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showMovieCreditHandler(resp http.ResponseWriter, req *http.Request) {
+	credit, ok := app.readCredit(resp, req)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(resp, http.StatusOK, envelope{"credit": credit}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) updateMovieCreditHandler(resp http.ResponseWriter, req *http.Request) {
+	credit, ok := app.readCredit(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PersonID  *int64  `json:"person_id"`
+		Role      *string `json:"role"`
+		Character *string `json:"character"`
+		Billing   *int32  `json:"billing"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.PersonID != nil {
+		credit.PersonID = *input.PersonID
+	}
+	if input.Role != nil {
+		credit.Role = *input.Role
+	}
+	if input.Character != nil {
+		credit.Character = *input.Character
+	}
+	if input.Billing != nil {
+		credit.Billing = *input.Billing
+	}
+
+	v := validator.New()
+	if data.ValidateCredit(v, credit); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Credits.Update(req.Context(), credit)
+	if err != nil {
+		app.creditErrorResponse(resp, req, v, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"credit": credit}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) deleteMovieCreditHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	id, err := app.readCreditIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	err = app.models.Credits.Delete(req.Context(), movieID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "credit successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) readCredit(resp http.ResponseWriter, req *http.Request) (*data.Credit, bool) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := app.readCreditIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return nil, false
+	}
+
+	credit, err := app.models.Credits.Get(req.Context(), movieID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return nil, false
+	}
+
+	return credit, true
+}
+
+func (app *application) creditErrorResponse(resp http.ResponseWriter, req *http.Request, v *validator.Validator, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		v.AddError("person_id", "does not exist")
+		app.failedValidationResponse(resp, req, v.Errors)
+	case errors.Is(err, data.ErrDuplicateCredit):
+		v.AddError("person_id", "is already credited in this role and character")
+		app.failedValidationResponse(resp, req, v.Errors)
+	case errors.Is(err, data.ErrEditConflict):
+		app.editConflictResponse(resp, req)
+	default:
+		app.serverErrorResponse(resp, req, err)
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"greenlight/internal/data"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
+const statusClientClosedRequest = 499
+
 func (app *application) logError(req *http.Request, err error) {
 	app.logger.Error(err.Error(), slog.String("method", req.Method), slog.String("uri", req.URL.RequestURI()))
 }
@@ -19,8 +24,26 @@ func (app *application) errorResponse(resp http.ResponseWriter, req *http.Reques
 }
 
 func (app *application) serverErrorResponse(resp http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrQueryCanceled):
+		app.clientClosedRequestResponse(resp, req, err)
+	case errors.Is(err, data.ErrQueryTimeout):
+		app.serviceUnavailableResponse(resp, req, err)
+	default:
+		app.logError(req, err)
+		app.errorResponse(resp, req, http.StatusInternalServerError, "The server encountered a problem and could not process your request")
+	}
+}
+
+func (app *application) clientClosedRequestResponse(resp http.ResponseWriter, req *http.Request, err error) {
+	app.logger.Warn(err.Error(), slog.String("method", req.Method), slog.String("uri", req.URL.RequestURI()))
+	app.errorResponse(resp, req, statusClientClosedRequest, "the request was canceled by the client")
+}
+
+func (app *application) serviceUnavailableResponse(resp http.ResponseWriter, req *http.Request, err error) {
 	app.logError(req, err)
-	app.errorResponse(resp, req, http.StatusInternalServerError, "The server encountered a problem and could not process your request")
+	message := "the server is currently unable to handle the request, please try again later"
+	app.errorResponse(resp, req, http.StatusServiceUnavailable, message)
 }
 
 func (app *application) notFoundErrorRespone(resp http.ResponseWriter, req *http.Request) {
@@ -36,6 +59,16 @@ func (app *application) badRequestResponse(resp http.ResponseWriter, req *http.R
 	app.errorResponse(resp, req, http.StatusBadRequest, err.Error())
 }
 
+func (app *application) unsupportedMediaTypeResponse(resp http.ResponseWriter, req *http.Request, supported ...string) {
+	message := fmt.Sprintf("The request content type must be one of: %s", strings.Join(supported, ", "))
+	app.errorResponse(resp, req, http.StatusUnsupportedMediaType, message)
+}
+
+func (app *application) notAcceptableResponse(resp http.ResponseWriter, req *http.Request, supported ...string) {
+	message := fmt.Sprintf("The response can only be provided as one of: %s", strings.Join(supported, ", "))
+	app.errorResponse(resp, req, http.StatusNotAcceptable, message)
+}
+
 func (app *application) failedValidationResponse(resp http.ResponseWriter, req *http.Request, errors map[string]string) {
 	app.errorResponse(resp, req, http.StatusUnprocessableEntity, errors)
 }
@@ -45,6 +78,21 @@ func (app *application) editConflictResponse(resp http.ResponseWriter, req *http
 	app.errorResponse(resp, req, http.StatusConflict, message)
 }
 
+func (app *application) genreInUseResponse(resp http.ResponseWriter, req *http.Request) {
+	message := "the genre is used by one or more movies and cannot be deleted"
+	app.errorResponse(resp, req, http.StatusConflict, message)
+}
+
+func (app *application) preconditionFailedResponse(resp http.ResponseWriter, req *http.Request) {
+	message := "the resource has been modified since you last fetched it"
+	app.errorResponse(resp, req, http.StatusPreconditionFailed, message)
+}
+
+func (app *application) preconditionRequiredResponse(resp http.ResponseWriter, req *http.Request) {
+	message := "this request must be made conditional with an If-Match header"
+	app.errorResponse(resp, req, http.StatusPreconditionRequired, message)
+}
+
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 	message := "rate limit exceeded"
 	app.errorResponse(w, r, http.StatusTooManyRequests, message)
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFlushInterval is the number of movies written between flushes of the
+// response, so clients see progress on long exports.
+const exportFlushInterval = 100
+
+type movieEncoder interface {
+	begin() error
+	encode(movie *data.Movie) error
+	flush() error
+	end() error
+}
+
+func (app *application) exportMoviesHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		data.MovieFilter
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.MovieFilter = app.readMovieFilter(qs, v)
+
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if data.ValidateMovieFilter(v, &input.MovieFilter, vocabulary); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	offers := []string{"application/json", "application/x-ndjson", "text/csv"}
+
+	contentType := negotiateContentType(req.Header.Get("Accept"), offers...)
+	if contentType == "" {
+		app.notAcceptableResponse(resp, req, offers...)
+		return
+	}
+
+	var (
+		encoder   movieEncoder
+		extension string
+	)
+	switch contentType {
+	case "application/x-ndjson":
+		encoder, extension = &jsonLinesEncoder{w: bufio.NewWriter(resp)}, "jsonl"
+	case "text/csv":
+		encoder, extension = &csvEncoder{w: csv.NewWriter(resp)}, "csv"
+	default:
+		encoder, extension = &jsonArrayEncoder{w: bufio.NewWriter(resp)}, "json"
+	}
+
+	// The export can run for far longer than the server write timeout, so the
+	// deadline is lifted for this response only.
+	rc := http.NewResponseController(resp)
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	started := false
+	start := func() error {
+		started = true
+		resp.Header().Set("Content-Type", contentType)
+		resp.Header().Set("Content-Disposition", `attachment; filename="movies.`+extension+`"`)
+		resp.WriteHeader(http.StatusOK)
+		return encoder.begin()
+	}
+
+	count := 0
+	err = app.models.Movies.Export(req.Context(), input.MovieFilter, func(movie *data.Movie) error {
+		if !started {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+
+		err := encoder.encode(movie)
+		if err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushInterval != 0 {
+			return nil
+		}
+
+		err = encoder.flush()
+		if err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = encoder.end()
+	}
+	if err != nil {
+		if !started {
+			app.serverErrorResponse(resp, req, err)
+			return
+		}
+
+		// The status line has already been sent, so the only way left to tell
+		// the client the export is incomplete is to abort the connection.
+		app.logError(req, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+type jsonLinesEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *jsonLinesEncoder) begin() error {
+	return nil
+}
+
+func (e *jsonLinesEncoder) encode(movie *data.Movie) error {
+	return json.NewEncoder(e.w).Encode(movie)
+}
+
+func (e *jsonLinesEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonLinesEncoder) end() error {
+	return e.w.Flush()
+}
+
+type jsonArrayEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (e *jsonArrayEncoder) begin() error {
+	_, err := e.w.WriteString("[")
+	return err
+}
+
+func (e *jsonArrayEncoder) encode(movie *data.Movie) error {
+	if e.count > 0 {
+		_, err := e.w.WriteString(",")
+		if err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	js, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(js)
+	return err
+}
+
+func (e *jsonArrayEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonArrayEncoder) end() error {
+	_, err := e.w.WriteString("]\n")
+	if err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write([]string{"id", "title", "year", "runtime", "genres", "version"})
+}
+
+func (e *csvEncoder) encode(movie *data.Movie) error {
+	return e.w.Write([]string{
+		strconv.FormatInt(movie.ID, 10),
+		movie.Title,
+		strconv.Itoa(int(movie.Year)),
+		movie.Runtime.String(),
+		strings.Join(movie.Genres, "|"),
+		strconv.Itoa(int(movie.Version)),
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error {
+	return e.flush()
+}
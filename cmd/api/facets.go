@@ -0,0 +1,49 @@
+package main
+
+import (
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) movieFacetsHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		data.MovieFilter
+		YearInterval string
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.MovieFilter = app.readMovieFilter(qs, v)
+	input.YearInterval = app.readString(qs, "year_interval", "decade")
+
+	v.Check(validator.PermittedValue(input.YearInterval, "year", "decade"), "year_interval", "must be year or decade")
+
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if data.ValidateMovieFilter(v, &input.MovieFilter, vocabulary); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	yearInterval := 10
+	if input.YearInterval == "year" {
+		yearInterval = 1
+	}
+
+	facets, err := app.models.Movies.Facets(req.Context(), input.MovieFilter, yearInterval)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"facets": facets}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) listGenresHandler(resp http.ResponseWriter, req *http.Request) {
+	genres, err := app.models.Genres.GetAll(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"genres": genres}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) createGenreHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		Slug    string   `json:"slug"`
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	genre := &data.Genre{
+		Slug:    input.Slug,
+		Name:    input.Name,
+		Aliases: input.Aliases,
+	}
+
+	v := validator.New()
+	if data.ValidateGenre(v, genre); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Genres.Insert(req.Context(), genre)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateGenre):
+			v.AddError("slug", "the slug or an alias is already used by another genre")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	app.genres.delete(genreVocabularyKey)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/genres/%s", genre.Slug))
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"genre": genre}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showGenreHandler(resp http.ResponseWriter, req *http.Request) {
+	genre, err := app.models.Genres.Get(req.Context(), app.readSlugParam(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"genre": genre}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) updateGenreHandler(resp http.ResponseWriter, req *http.Request) {
+	genre, err := app.models.Genres.Get(req.Context(), app.readSlugParam(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	var input struct {
+		Name    *string  `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+
+	err = app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.Name != nil {
+		genre.Name = *input.Name
+	}
+	if input.Aliases != nil {
+		genre.Aliases = input.Aliases
+	}
+
+	v := validator.New()
+	if data.ValidateGenre(v, genre); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Genres.Update(req.Context(), genre)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateGenre):
+			v.AddError("aliases", "must not contain the slug or an alias of another genre")
+			app.failedValidationResponse(resp, req, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	app.genres.delete(genreVocabularyKey)
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"genre": genre}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) deleteGenreHandler(resp http.ResponseWriter, req *http.Request) {
+	err := app.models.Genres.Delete(req.Context(), app.readSlugParam(req))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		case errors.Is(err, data.ErrGenreInUse):
+			app.genreInUseResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	app.genres.delete(genreVocabularyKey)
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "genre successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// genreVocabularyKey is the only key of the genres cache.
+const genreVocabularyKey = "vocabulary"
+
+// genreVocabulary returns the cached genre vocabulary, loading it on a miss.
+// Genre writes through this instance drop the cache straight away; other
+// instances catch up once the entry expires.
+func (app *application) genreVocabulary(ctx context.Context) (data.GenreVocabulary, error) {
+	vocabulary, ok := app.genres.get(genreVocabularyKey)
+	if ok {
+		return vocabulary, nil
+	}
+
+	genres, err := app.models.Genres.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vocabulary = data.NewGenreVocabulary(genres)
+	app.genres.set(genreVocabularyKey, vocabulary)
+
+	return vocabulary, nil
+}
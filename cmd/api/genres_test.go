@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenreVocabularyCacheInvalidation(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, token := insertTestUser(t, app, "alice@example.com", "movies:read", "genres:write")
+
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		filter     string
+		wantStatus int
+	}{
+		{"unknown genre", "", "", "", "mockumentary", http.StatusUnprocessableEntity},
+		{"created genre", http.MethodPost, "/v1/genres", `{"slug": "mockumentary", "name": "Mockumentary"}`, "mockumentary", http.StatusOK},
+		{"added alias", http.MethodPatch, "/v1/genres/mockumentary", `{"aliases": ["mock-doc"]}`, "mock-doc", http.StatusOK},
+		{"deleted genre", http.MethodDelete, "/v1/genres/mockumentary", ``, "mock-doc", http.StatusUnprocessableEntity},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.method != "" {
+				status, _, body := ts.do(t, step.method, step.path, token, step.body)
+				if status >= http.StatusBadRequest {
+					t.Fatalf("got status %d: %s", status, body)
+				}
+			}
+
+			status, _, body := ts.do(t, http.MethodGet, "/v1/movies?genres="+step.filter, token, "")
+			if status != step.wantStatus {
+				t.Errorf("got status %d filtering by %s; want %d: %s", status, step.filter, step.wantStatus, body)
+			}
+		})
+	}
+}
+
+func TestCreateMovieWithDeletedGenre(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, token := insertTestUser(t, app, "alice@example.com", "movies:read", "movies:write")
+
+	// Warm the vocabulary cache, then delete the genre behind its back as
+	// another instance would.
+	status, _, body := ts.do(t, http.MethodGet, "/v1/movies?genres=western", token, "")
+	if status != http.StatusOK {
+		t.Fatalf("got status %d listing; want %d: %s", status, http.StatusOK, body)
+	}
+
+	err := app.models.Genres.Delete(context.Background(), "western")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movie := `{"title": "Unforgiven", "year": 1992, "runtime": "130 mins", "genres": ["western"]}`
+	status, _, body = ts.do(t, http.MethodPost, "/v1/movies", token, movie)
+	if status != http.StatusUnprocessableEntity || !strings.Contains(body, "no longer exists") {
+		t.Errorf("got status %d; want %d for a deleted genre: %s", status, http.StatusUnprocessableEntity, body)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"greenlight/internal/data"
 	"greenlight/internal/validator"
+	"hash/fnv"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -26,6 +30,80 @@ func (app *application) readIDParam(req *http.Request) (int64, error) {
 	return id, nil
 }
 
+func (app *application) readVersionParam(req *http.Request) (int32, error) {
+	params := httprouter.ParamsFromContext(req.Context())
+	version, err := strconv.ParseInt(params.ByName("version"), 10, 32)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version parameter")
+	}
+
+	return int32(version), nil
+}
+
+func (app *application) readCreditIDParam(req *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(req.Context())
+	id, err := strconv.ParseInt(params.ByName("credit_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid credit id parameter")
+	}
+
+	return id, nil
+}
+
+func (app *application) readReviewIDParam(req *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(req.Context())
+	id, err := strconv.ParseInt(params.ByName("review_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid review id parameter")
+	}
+
+	return id, nil
+}
+
+func (app *application) readMovieIDParam(req *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(req.Context())
+	id, err := strconv.ParseInt(params.ByName("movie_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid movie id parameter")
+	}
+
+	return id, nil
+}
+
+// readMovieID returns the id of the movie in the URL for handlers of its
+// subresources, writing a not found response if the movie does not exist or
+// is in the trash.
+func (app *application) readMovieID(resp http.ResponseWriter, req *http.Request) (int64, bool) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return 0, false
+	}
+
+	_, err = app.models.Movies.Get(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (app *application) readSlugParam(req *http.Request) string {
+	params := httprouter.ParamsFromContext(req.Context())
+	return params.ByName("slug")
+}
+
+func (app *application) readShareIDParam(req *http.Request) string {
+	params := httprouter.ParamsFromContext(req.Context())
+	return params.ByName("id")
+}
+
 func (app *application) writeJSON(resp http.ResponseWriter, status int, data envelope, headers http.Header) error {
 	js, err := json.Marshal(data)
 	if err != nil {
@@ -100,6 +178,123 @@ func (app *application) readJSON(resp http.ResponseWriter, req *http.Request, ds
 	return nil
 }
 
+func strongETag(id int64, version int64) string {
+	return fmt.Sprintf(`"%d-%d"`, id, version)
+}
+
+// movieETag is the weak validator served with movies for caching. Besides the
+// id and version it covers the rating aggregates and any loaded credits, which
+// change without bumping the version.
+func movieETag(movie *data.Movie) string {
+	etag := fmt.Sprintf("%d-%d-%d-%g", movie.ID, movie.Version, movie.RatingCount, movie.AverageRating)
+
+	if movie.Credits != nil {
+		hash := fnv.New64a()
+		for _, credit := range movie.Credits {
+			fmt.Fprintf(hash, "%d\x00%d\x00%s\x00%s\x00%s\x00%d\x00", credit.ID, credit.PersonID, credit.Name, credit.Role, credit.Character, credit.Billing)
+		}
+		etag = fmt.Sprintf("%s-%x", etag, hash.Sum64())
+	}
+
+	return `W/"` + etag + `"`
+}
+
+// etagMatches reports whether etag is listed in an If-Match or If-None-Match
+// header value. Weak comparison ignores the W/ prefix on either side, as
+// required for If-None-Match; strong comparison never matches weak validators.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if !weak && (strings.HasPrefix(candidate, "W/") || strings.HasPrefix(etag, "W/")) {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (app *application) notModified(resp http.ResponseWriter, req *http.Request, etag string) bool {
+	ifNoneMatch := req.Header.Get("If-None-Match")
+	if ifNoneMatch == "" || !etagMatches(ifNoneMatch, etag, true) {
+		return false
+	}
+
+	resp.Header().Set("ETag", etag)
+	resp.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (app *application) checkIfMatch(resp http.ResponseWriter, req *http.Request, etag string) bool {
+	ifMatch := req.Header.Get("If-Match")
+
+	switch {
+	case ifMatch == "" && app.config.requireIfMatch:
+		app.preconditionRequiredResponse(resp, req)
+		return false
+	case ifMatch != "" && !etagMatches(ifMatch, etag, false):
+		app.preconditionFailedResponse(resp, req)
+		return false
+	}
+
+	return true
+}
+
+// checkMovieIfMatch guards movie writes with the strong id+version validator.
+// A movie ETag with the current id and version is accepted in its place, so a
+// rating by another user does not fail an edit.
+func (app *application) checkMovieIfMatch(resp http.ResponseWriter, req *http.Request, movie *data.Movie) bool {
+	current := fmt.Sprintf(`W/"%d-%d-`, movie.ID, movie.Version)
+	for _, candidate := range strings.Split(req.Header.Get("If-Match"), ",") {
+		if strings.HasPrefix(strings.TrimSpace(candidate), current) {
+			return true
+		}
+	}
+
+	return app.checkIfMatch(resp, req, strongETag(movie.ID, int64(movie.Version)))
+}
+
+// negotiateContentType returns the offer best matching an Accept header, or
+// the first offer when the header is empty. It returns "" if nothing matches.
+func negotiateContentType(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQuality := "", 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= bestQuality {
+			continue
+		}
+
+		for _, offer := range offers {
+			if mediaType == offer || mediaType == "*/*" || (strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*"))) {
+				best, bestQuality = offer, quality
+				break
+			}
+		}
+	}
+
+	return best
+}
+
 func (app *application) readString(qs url.Values, key string, defaultValue string) string {
 	s := qs.Get(key)
 	if s == "" {
@@ -133,6 +328,54 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readRuntime(qs url.Values, key string, v *validator.Validator) data.Runtime {
+	s := qs.Get(key)
+	if s == "" {
+		return 0
+	}
+
+	runtime, err := data.ParseRuntime(s)
+	if err != nil {
+		v.AddError(key, "must be a duration such as \"102 mins\" or \"1h42m\"")
+		return 0
+	}
+
+	return runtime
+}
+
+func (app *application) readTime(qs url.Values, key string, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+	if s == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, s)
+	}
+	if err != nil {
+		v.AddError(key, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		return time.Time{}
+	}
+
+	return t
+}
+
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
 
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		weak   bool
+		want   bool
+	}{
+		{name: "exact", header: `"1-2"`, etag: `"1-2"`, want: true},
+		{name: "different", header: `"1-3"`, etag: `"1-2"`, want: false},
+		{name: "list", header: `"1-1", "1-2"`, etag: `"1-2"`, want: true},
+		{name: "wildcard", header: `*`, etag: `"1-2"`, want: true},
+		{name: "weak validator strong comparison", header: `W/"1-2"`, etag: `"1-2"`, want: false},
+		{name: "weak validator weak comparison", header: `W/"1-2"`, etag: `"1-2"`, weak: true, want: true},
+		{name: "weak etag weak comparison", header: `"1-2"`, etag: `W/"1-2"`, weak: true, want: true},
+		{name: "weak etag strong comparison", header: `W/"1-2"`, etag: `W/"1-2"`, want: false},
+		{name: "unquoted", header: `1-2`, etag: `"1-2"`, want: false},
+		{name: "empty", header: ``, etag: `"1-2"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := etagMatches(tt.header, tt.etag, tt.weak)
+			if got != tt.want {
+				t.Errorf("etagMatches(%q, %q, %t) = %t; want %t", tt.header, tt.etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/json", "application/x-ndjson", "text/csv"}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "empty", accept: "", want: "application/json"},
+		{name: "exact", accept: "text/csv", want: "text/csv"},
+		{name: "any", accept: "*/*", want: "application/json"},
+		{name: "subtype wildcard", accept: "text/*", want: "text/csv"},
+		{name: "quality", accept: "application/json;q=0.5, text/csv", want: "text/csv"},
+		{name: "first of equal quality", accept: "application/x-ndjson, text/csv", want: "application/x-ndjson"},
+		{name: "unsupported", accept: "application/xml", want: ""},
+		{name: "zero quality", accept: "text/csv;q=0", want: ""},
+		{name: "malformed quality skipped", accept: "text/csv;q=abc, application/x-ndjson", want: "application/x-ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := negotiateContentType(tt.accept, offers...)
+			if got != tt.want {
+				t.Errorf("negotiateContentType(%q) = %q; want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	importModeAtomic     = "atomic"
+	importModeBestEffort = "best_effort"
+)
+
+type importRow struct {
+	line   int
+	movie  *data.Movie
+	errors map[string]string
+}
+
+// addError records message for key unless the row already has an error for
+// it, so parse errors take precedence over the validation that follows.
+func (row *importRow) addError(key, message string) {
+	if row.errors == nil {
+		row.errors = make(map[string]string)
+	}
+	if _, exists := row.errors[key]; !exists {
+		row.errors[key] = message
+	}
+}
+
+type importResult struct {
+	Line   int               `json:"line"`
+	ID     int64             `json:"id,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type importReport struct {
+	Mode    string         `json:"mode"`
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Rows    []importResult `json:"rows"`
+}
+
+func (app *application) importMoviesHandler(resp http.ResponseWriter, req *http.Request) {
+	v := validator.New()
+
+	mode := app.readString(req.URL.Query(), "mode", importModeAtomic)
+	if v.Check(validator.PermittedValue(mode, importModeAtomic, importModeBestEffort), "mode", "must be atomic or best_effort"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	var parse func(io.Reader) ([]*importRow, error)
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		parse = parseMovieCSV
+	case "application/x-ndjson":
+		parse = parseMovieNDJSON
+	default:
+		app.unsupportedMediaTypeResponse(resp, req, "text/csv", "application/x-ndjson")
+		return
+	}
+
+	// Imports are allowed to take longer than the server-wide timeouts, which
+	// are sized for ordinary JSON requests.
+	if app.config.moviesImport.timeout > 0 {
+		rc := http.NewResponseController(resp)
+		deadline := time.Now().Add(app.config.moviesImport.timeout)
+		for _, set := range []func(time.Time) error{rc.SetReadDeadline, rc.SetWriteDeadline} {
+			err := set(deadline)
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				app.serverErrorResponse(resp, req, err)
+				return
+			}
+		}
+	}
+
+	req.Body = http.MaxBytesReader(resp, req.Body, app.config.moviesImport.maxBytes)
+
+	rows, err := parse(req.Body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			err = fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		}
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if len(rows) == 0 {
+		app.badRequestResponse(resp, req, errors.New("body must contain at least one movie"))
+		return
+	}
+
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	// Rows with field parse errors are still validated, so the report lists
+	// every problem with them at once. Only rows that could not be read at all
+	// have no movie.
+	var valid []*importRow
+	for _, row := range rows {
+		if row.movie == nil {
+			continue
+		}
+
+		v := validator.New()
+		data.ValidateMovie(v, row.movie, vocabulary)
+		for key, message := range v.Errors {
+			row.addError(key, message)
+		}
+
+		if row.errors == nil {
+			valid = append(valid, row)
+		}
+	}
+
+	report := importReport{Mode: mode, Total: len(rows)}
+	user := app.contextGetUser(req)
+
+	switch mode {
+	case importModeAtomic:
+		if len(valid) < len(rows) {
+			for _, row := range rows {
+				if row.errors != nil {
+					report.Failed++
+					report.Rows = append(report.Rows, importResult{Line: row.line, Errors: row.errors})
+				}
+			}
+
+			err = app.writeJSON(resp, http.StatusUnprocessableEntity, envelope{"import": report}, nil)
+			if err != nil {
+				app.serverErrorResponse(resp, req, err)
+			}
+			return
+		}
+
+		err = app.insertImportedMovies(req.Context(), valid, user.ID)
+
+	case importModeBestEffort:
+		// A failed batch is reported row by row and the import carries on,
+		// unless the request itself was canceled or timed out.
+		batchSize := max(app.config.moviesImport.batchSize, 1)
+		for start := 0; start < len(valid); start += batchSize {
+			batch := valid[start:min(start+batchSize, len(valid))]
+
+			err = app.insertImportedMovies(req.Context(), batch, user.ID)
+			if err == nil {
+				continue
+			}
+			if errors.Is(err, data.ErrQueryCanceled) || errors.Is(err, data.ErrQueryTimeout) {
+				break
+			}
+
+			app.logger.Error(err.Error(), "lines", fmt.Sprintf("%d-%d", batch[0].line, batch[len(batch)-1].line))
+			for _, row := range batch {
+				row.addError("movie", fmt.Sprintf("could not be saved: %v", err))
+			}
+			err = nil
+		}
+	}
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	for _, row := range rows {
+		if row.errors != nil {
+			report.Failed++
+			report.Rows = append(report.Rows, importResult{Line: row.line, Errors: row.errors})
+			continue
+		}
+
+		report.Created++
+		report.Rows = append(report.Rows, importResult{Line: row.line, ID: row.movie.ID})
+	}
+
+	status := http.StatusCreated
+	if report.Created == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	err = app.writeJSON(resp, status, envelope{"import": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) insertImportedMovies(ctx context.Context, rows []*importRow, userID int64) error {
+	return app.models.WithTx(ctx, func(tx data.Models) error {
+		for _, row := range rows {
+			err := tx.Movies.Insert(ctx, row.movie)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Revisions.Insert(ctx, data.NewMovieRevision(row.movie, data.RevisionCreate, userID))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// parseMovieCSV reads movies from CSV with a header row naming the title,
+// year, runtime and genres columns in any order. Genres are separated by "|".
+func parseMovieCSV(r io.Reader) ([]*importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("body contains malformed CSV: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !validator.PermittedValue(name, "title", "year", "runtime", "genres") {
+			return nil, fmt.Errorf("body contains unknown CSV column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("body contains duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+
+	for _, name := range []string{"title", "year", "runtime", "genres"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("body is missing the CSV column %q", name)
+		}
+	}
+
+	var rows []*importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var parseError *csv.ParseError
+		switch {
+		case errors.As(err, &parseError) && errors.Is(parseError.Err, csv.ErrFieldCount):
+			line, _ := reader.FieldPos(0)
+			rows = append(rows, &importRow{line: line, errors: map[string]string{
+				"row": fmt.Sprintf("must contain %d fields", len(header)),
+			}})
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("body contains malformed CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &importRow{line: line, movie: &data.Movie{
+			Title:  record[columns["title"]],
+			Genres: []string{},
+		}}
+
+		if year := strings.TrimSpace(record[columns["year"]]); year != "" {
+			i, err := strconv.ParseInt(year, 10, 32)
+			if err != nil {
+				row.addError("year", "must be an integer value")
+			}
+			row.movie.Year = int32(i)
+		}
+
+		if runtime := strings.TrimSpace(record[columns["runtime"]]); runtime != "" {
+			row.movie.Runtime, err = data.ParseRuntime(runtime)
+			if err != nil {
+				row.addError("runtime", "must be a duration such as \"102 mins\" or \"1h42m\"")
+			}
+		}
+
+		if genres := strings.TrimSpace(record[columns["genres"]]); genres != "" {
+			for _, genre := range strings.Split(genres, "|") {
+				row.movie.Genres = append(row.movie.Genres, strings.TrimSpace(genre))
+			}
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// parseMovieNDJSON reads one movie object per line, skipping blank lines.
+func parseMovieNDJSON(r io.Reader) ([]*importRow, error) {
+	reader := bufio.NewReader(r)
+
+	var rows []*importRow
+	for line := 1; ; line++ {
+		b, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			rows = append(rows, parseMovieJSONLine(line, b))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+	}
+}
+
+func parseMovieJSONLine(line int, b []byte) *importRow {
+	var input struct {
+		Title   string       `json:"title"`
+		Year    int32        `json:"year"`
+		Runtime data.Runtime `json:"runtime"`
+		Genres  []string     `json:"genres"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&input)
+	if err == nil && !errors.Is(decoder.Decode(&struct{}{}), io.EOF) {
+		return &importRow{line: line, errors: map[string]string{"row": "must contain a single JSON object"}}
+	}
+	if err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+
+		message := "contains badly-formed JSON"
+		switch {
+		case errors.Is(err, data.ErrInvalidRuntimeFormat):
+			return &importRow{line: line, errors: map[string]string{"runtime": "must be a duration such as \"102 mins\" or \"1h42m\""}}
+		case errors.As(err, &unmarshalTypeError) && unmarshalTypeError.Field != "":
+			message = fmt.Sprintf("contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			message = "contains unknown key " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+		}
+
+		return &importRow{line: line, errors: map[string]string{"row": message}}
+	}
+
+	return &importRow{line: line, movie: &data.Movie{
+		Title:   input.Title,
+		Year:    input.Year,
+		Runtime: input.Runtime,
+		Genres:  input.Genres,
+	}}
+}
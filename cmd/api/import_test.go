@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestImportMoviesRowErrors(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, token := insertTestUser(t, app, "alice@example.com", "movies:read", "movies:write")
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        map[int]map[string]string
+	}{
+		{
+			name:        "csv parse and validation errors",
+			contentType: "text/csv",
+			body:        "title,year,runtime,genres\n,19x2,long,western|nonsense\nUnforgiven,1992,130 mins,western\n",
+			want: map[int]map[string]string{
+				2: {
+					"title":   "must be provided",
+					"year":    "must be an integer value",
+					"runtime": `must be a duration such as "102 mins" or "1h42m"`,
+					"genres":  `contains unknown genre "nonsense"`,
+				},
+			},
+		},
+		{
+			name:        "ndjson trailing data",
+			contentType: "application/x-ndjson",
+			body:        `{"title": "Unforgiven", "year": 1992, "runtime": "130 mins", "genres": ["western"]}]` + "\n" + `{"title": "Heat", "year": 1995, "runtime": "170 mins", "genres": ["crime"]} x` + "\n]\n",
+			want: map[int]map[string]string{
+				1: {"row": "must contain a single JSON object"},
+				2: {"row": "must contain a single JSON object"},
+				3: {"row": "contains badly-formed JSON"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, body := ts.do(t, http.MethodPost, "/v1/movies/import?mode=best_effort", token, tt.body, "Content-Type", tt.contentType)
+
+			var got struct {
+				Import importReport `json:"import"`
+			}
+			decodeTestJSON(t, body, &got)
+
+			for _, row := range got.Import.Rows {
+				want := tt.want[row.Line]
+				if len(want) == 0 && len(row.Errors) == 0 {
+					continue
+				}
+				if !reflect.DeepEqual(row.Errors, want) {
+					t.Errorf("got errors %v on line %d; want %v", row.Errors, row.Line, want)
+				}
+			}
+			if got.Import.Failed != len(tt.want) {
+				t.Errorf("got %d failed rows; want %d", got.Import.Failed, len(tt.want))
+			}
+		})
+	}
+}
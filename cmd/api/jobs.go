@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+func (app *application) purgeDeletedMovies(ctx context.Context) {
+	if app.config.trash.purgeInterval <= 0 {
+		return
+	}
+
+	app.background(func() {
+		ticker := time.NewTicker(app.config.trash.purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			before := time.Now().Add(-app.config.trash.retention)
+
+			ids, err := app.models.Movies.PurgeDeletedBefore(ctx, before)
+			if err != nil {
+				app.logger.Error(err.Error())
+				continue
+			}
+
+			if len(ids) > 0 {
+				app.logger.Info("purged deleted movies", "count", len(ids))
+				app.deletePosters(ctx, ids...)
+			}
+		}
+	})
+}
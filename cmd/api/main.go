@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"expvar"
 	"flag"
 	"fmt"
 	"greenlight/internal/data"
 	"greenlight/internal/mailer"
+	"greenlight/internal/migrate"
+	"greenlight/internal/storage"
+	"greenlight/migrations"
 	"log/slog"
 	"os"
 	"runtime"
@@ -15,24 +19,30 @@ import (
 	"sync"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const version = "1.0.0"
 
 type config struct {
-	port int
-	env  string
-	db   struct {
+	port    int
+	env     string
+	storage string
+	db      struct {
 		dsn             string
 		maxOpenConns    int
 		maxIdleConns    int
 		ConnMaxIdleTime time.Duration
+		readTimeout     time.Duration
+		writeTimeout    time.Duration
+		autoMigrate     bool
 	}
 	limiter struct {
-		rps     float64
-		burst   int
-		enabled bool
+		rps          float64
+		burst        int
+		suggestRPS   float64
+		suggestBurst int
+		enabled      bool
 	}
 	smtp struct {
 		host     string
@@ -44,6 +54,28 @@ type config struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	trash struct {
+		retention     time.Duration
+		purgeInterval time.Duration
+	}
+	moviesImport struct {
+		maxBytes  int64
+		batchSize int
+		timeout   time.Duration
+	}
+	posters struct {
+		dir      string
+		maxBytes int64
+	}
+	tokens struct {
+		accessTTL  time.Duration
+		refreshTTL time.Duration
+	}
+	searchLanguage string
+	suggestTTL     time.Duration
+	genreTTL       time.Duration
+	cursorSecret   []byte
+	requireIfMatch bool
 }
 
 type application struct {
@@ -51,21 +83,31 @@ type application struct {
 	models data.Models
 	logger *slog.Logger
 	mailer mailer.Mailer
+	blobs  storage.Store
 	wg     sync.WaitGroup
+
+	suggestions *ttlCache[[]*data.MovieSuggestion]
+	genres      *ttlCache[data.GenreVocabulary]
 }
 
 func main() {
 	var cfg config
 	flag.IntVar(&cfg.port, "port", 4000, "API server port")
 	flag.StringVar(&cfg.env, "env", "development", "Environment (development|staging|production)")
+	flag.StringVar(&cfg.storage, "storage", "postgres", "Storage backend (memory|postgres)")
 
 	flag.StringVar(&cfg.db.dsn, "dsn", os.Getenv("GREENLIGHT_DB_DSN"), "PostgresSQL DSN")
 	flag.IntVar(&cfg.db.maxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
 	flag.IntVar(&cfg.db.maxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
 	flag.DurationVar(&cfg.db.ConnMaxIdleTime, "db-conn-max-idle-time", 15*time.Minute, "PostgreSQL connection max idle time")
+	flag.DurationVar(&cfg.db.readTimeout, "db-read-timeout", 3*time.Second, "PostgreSQL timeout for read queries")
+	flag.DurationVar(&cfg.db.writeTimeout, "db-write-timeout", 5*time.Second, "PostgreSQL timeout for write queries")
+	flag.BoolVar(&cfg.db.autoMigrate, "auto-migrate", false, "Apply pending database migrations on startup")
 
 	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
+	flag.Float64Var(&cfg.limiter.suggestRPS, "limiter-suggest-rps", 10, "Rate limiter maximum requests per second for title suggestions")
+	flag.IntVar(&cfg.limiter.suggestBurst, "limiter-suggest-burst", 20, "Rate limiter maximum burst for title suggestions")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", false, "Enable rate limiter")
 
 	flag.StringVar(&cfg.smtp.host, "smtp-host", "sandbox.smtp.mailtrap.io", "SMTP host")
@@ -79,6 +121,31 @@ func main() {
 		return nil
 	})
 
+	flag.Func("cursor-secret", "Secret for signing pagination cursors (default $GREENLIGHT_CURSOR_SECRET or random)", func(val string) error {
+		cfg.cursorSecret = []byte(val)
+		return nil
+	})
+
+	flag.DurationVar(&cfg.trash.retention, "trash-retention", 30*24*time.Hour, "How long deleted movies are kept before being purged")
+	flag.DurationVar(&cfg.trash.purgeInterval, "trash-purge-interval", time.Hour, "How often to purge expired deleted movies (0 disables)")
+
+	flag.Int64Var(&cfg.moviesImport.maxBytes, "import-max-bytes", 32<<20, "Maximum size of a movie import body in bytes")
+	flag.IntVar(&cfg.moviesImport.batchSize, "import-batch-size", 500, "Number of movies inserted per transaction in best effort imports")
+	flag.DurationVar(&cfg.moviesImport.timeout, "import-timeout", 2*time.Minute, "Read and write deadline for movie import requests (0 keeps the server timeouts)")
+
+	flag.StringVar(&cfg.posters.dir, "poster-dir", "uploads", "Directory where uploaded posters and their thumbnails are stored")
+	flag.Int64Var(&cfg.posters.maxBytes, "poster-max-bytes", 10<<20, "Maximum size of a poster upload in bytes")
+
+	flag.DurationVar(&cfg.tokens.accessTTL, "access-token-ttl", 15*time.Minute, "How long authentication tokens are valid")
+	flag.DurationVar(&cfg.tokens.refreshTTL, "refresh-token-ttl", 30*24*time.Hour, "How long a session can be kept alive with refresh tokens")
+
+	flag.StringVar(&cfg.searchLanguage, "search-language", "english", "PostgreSQL text search configuration for title search (movies_title_idx is built for english)")
+
+	flag.DurationVar(&cfg.suggestTTL, "suggest-cache-ttl", 30*time.Second, "How long title suggestions are cached (0 disables)")
+	flag.DurationVar(&cfg.genreTTL, "genre-cache-ttl", time.Minute, "How long the genre vocabulary is cached (0 disables)")
+
+	flag.BoolVar(&cfg.requireIfMatch, "require-if-match", false, "Reject movie updates and deletes without an If-Match header")
+
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
 	flag.Parse()
@@ -90,30 +157,109 @@ func main() {
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: false}))
 
-	db, err := openDB(cfg)
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
+	if len(cfg.cursorSecret) == 0 {
+		cfg.cursorSecret = []byte(os.Getenv("GREENLIGHT_CURSOR_SECRET"))
+	}
+	if len(cfg.cursorSecret) == 0 {
+		cfg.cursorSecret = make([]byte, 32)
+		_, err := rand.Read(cfg.cursorSecret)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Warn("no cursor secret configured, pagination cursors will not survive a restart")
+	}
+
+	command := flag.Args()
+	if len(command) > 0 && (command[0] != "migrate" || cfg.storage != "postgres") {
+		logger.Error(fmt.Sprintf("unsupported command %q with %s storage", strings.Join(command, " "), cfg.storage))
+		os.Exit(2)
 	}
-	defer db.Close()
-	logger.Info("database connection pool is established")
 
 	expvar.NewString("version").Set(version)
 	expvar.Publish("goroutines", expvar.Func(func() any {
 		return runtime.NumGoroutine()
 	}))
-	expvar.Publish("database", expvar.Func(func() any {
-		return db.Stats()
-	}))
 	expvar.Publish("timestamp", expvar.Func(func() any {
 		return time.Now().Unix()
 	}))
 
+	var models data.Models
+
+	switch cfg.storage {
+	case "postgres":
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer db.Close()
+		logger.Info("database connection pool is established")
+
+		migrationDB, err := openMigrationDB(cfg, logger)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer migrationDB.Close()
+
+		migrator, err := migrate.New(migrationDB, migrations.FS)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if len(command) > 0 {
+			err = runMigrateCommand(logger, migrator, command[1:])
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		err = prepareSchema(logger, migrator, cfg.db.autoMigrate)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		err = checkSearchLanguage(db, cfg.searchLanguage)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		expvar.Publish("database", expvar.Func(func() any {
+			return db.Stats()
+		}))
+
+		models = data.NewModels(db, data.Timeouts{
+			Read:  cfg.db.readTimeout,
+			Write: cfg.db.writeTimeout,
+		})
+	case "memory":
+		logger.Info("using in-memory storage, data will be lost on shutdown")
+		models = data.NewMemoryModels()
+	default:
+		logger.Error(fmt.Sprintf("unknown storage backend %q", cfg.storage))
+		os.Exit(1)
+	}
+
+	blobs, err := storage.NewFS(cfg.posters.dir)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	app := &application{
-		config: cfg,
-		models: data.NewModels(db),
-		logger: logger,
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		config:      cfg,
+		models:      models,
+		logger:      logger,
+		mailer:      mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		blobs:       blobs,
+		suggestions: newTTLCache[[]*data.MovieSuggestion](cfg.suggestTTL, 1000),
+		genres:      newTTLCache[data.GenreVocabulary](cfg.genreTTL, 1),
 	}
 
 	err = app.startServer()
@@ -123,6 +269,18 @@ func main() {
 	}
 }
 
+func checkSearchLanguage(db *sql.DB, language string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, "SELECT $1::regconfig", language)
+	if err != nil {
+		return fmt.Errorf("invalid search language %q: %w", language, err)
+	}
+
+	return nil
+}
+
 func openDB(cfg config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", cfg.db.dsn)
 	if err != nil {
@@ -144,3 +302,19 @@ func openDB(cfg config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// openMigrationDB opens a single connection for the migrator that logs the
+// notices migrations raise to report data they could not convert.
+func openMigrationDB(cfg config, logger *slog.Logger) (*sql.DB, error) {
+	connector, err := pq.NewConnector(cfg.db.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db := sql.OpenDB(pq.ConnectorWithNoticeHandler(connector, func(notice *pq.Error) {
+		logger.Warn(notice.Message, "severity", notice.Severity)
+	}))
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -26,6 +26,10 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				resp.Header().Set("Connection", "close")
 				app.serverErrorResponse(resp, req, fmt.Errorf("%s", err))
 			}
@@ -35,50 +39,75 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
-func (app *application) rateLimit(next http.Handler) http.Handler {
-	type client struct {
-		limiter  *rate.Limiter
-		lastSeen time.Time
+// clientLimiter keeps a token bucket per client IP address.
+type clientLimiter struct {
+	mu      sync.Mutex
+	rps     rate.Limit
+	burst   int
+	clients map[string]*rateLimitedClient
+}
+
+type rateLimitedClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientLimiter(rps float64, burst int) *clientLimiter {
+	return &clientLimiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		clients: make(map[string]*rateLimitedClient),
 	}
+}
 
-	var (
-		mu      sync.Mutex
-		clients = make(map[string]*client)
-	)
+func (l *clientLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, found := l.clients[ip]; !found {
+		l.clients[ip] = &rateLimitedClient{limiter: rate.NewLimiter(l.rps, l.burst)}
+	}
+	l.clients[ip].lastSeen = time.Now()
+
+	return l.clients[ip].limiter.Allow()
+}
+
+func (l *clientLimiter) forgetIdle(idle time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ip, client := range l.clients {
+		if time.Since(client.lastSeen) > idle {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	general := newClientLimiter(app.config.limiter.rps, app.config.limiter.burst)
+	suggest := newClientLimiter(app.config.limiter.suggestRPS, app.config.limiter.suggestBurst)
 
 	go func() {
 		for {
 			time.Sleep(time.Minute)
-			mu.Lock()
-
-			for ip, client := range clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-
-			mu.Unlock()
+			general.forgetIdle(3 * time.Minute)
+			suggest.forgetIdle(3 * time.Minute)
 		}
 	}()
 
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		if app.config.limiter.enabled {
-			ip := realip.FromRequest(req)
-
-			mu.Lock()
-
-			if _, found := clients[ip]; !found {
-				clients[ip] = &client{limiter: rate.NewLimiter(2, 4)}
+			// Typeahead requests arrive on every keystroke, so they draw from a
+			// separate, more generous bucket than the rest of the API.
+			limiter := general
+			if req.URL.Path == "/v1/movies/suggest" {
+				limiter = suggest
 			}
-			clients[ip].lastSeen = time.Now()
 
-			if !clients[ip].limiter.Allow() {
-				mu.Unlock()
+			if !limiter.allow(realip.FromRequest(req)) {
 				app.rateLimitExceededResponse(resp, req)
 				return
 			}
-
-			mu.Unlock()
 		}
 
 		next.ServeHTTP(resp, req)
@@ -96,20 +125,19 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		headerParts := strings.Split(authorizationHeader, " ")
-		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		token, ok := bearerToken(authorizationHeader)
+		if !ok {
 			app.invalidAuthenticationTokenResponse(resp, req)
 			return
 		}
 
-		token := headerParts[1]
 		v := validator.New()
 		if data.ValidateTokenPlaintext(v, token); !v.Valid() {
 			app.invalidAuthenticationTokenResponse(resp, req)
 			return
 		}
 
-		user, err := app.models.Users.GetForToken(data.ScopeAuthentication, token)
+		user, err := app.models.Users.GetForToken(req.Context(), data.ScopeAuthentication, token)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrRecordNotFound):
@@ -121,17 +149,37 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		// Recording the last use is bookkeeping, so a failure is logged rather
+		// than failing the request.
+		if user.TokenLastUsedAt == nil || time.Since(*user.TokenLastUsedAt) >= sessionTouchInterval {
+			err = app.models.Tokens.Touch(req.Context(), data.HashToken(token), sessionTouchInterval)
+			if err != nil {
+				app.logError(req, err)
+			}
+		}
+
 		req = app.contextSetUser(req, user)
 
 		next.ServeHTTP(resp, req)
 	})
 }
 
+// bearerToken returns the token of an Authorization header using the Bearer
+// scheme.
+func bearerToken(authorizationHeader string) (string, bool) {
+	headerParts := strings.Split(authorizationHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return "", false
+	}
+
+	return headerParts[1], true
+}
+
 func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
 	fn := func(resp http.ResponseWriter, req *http.Request) {
 		user := app.contextGetUser(req)
 
-		permissions, err := app.models.Permissions.GetAllForUser(user.ID)
+		permissions, err := app.models.Permissions.GetAllForUser(req.Context(), user.ID)
 		if err != nil {
 			app.serverErrorResponse(resp, req, err)
 			return
@@ -185,10 +233,11 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 				if origin == app.config.cors.trustedOrigins[i] {
 					resp.Header().Set("Access-Control-Allow-Origin", origin)
 					resp.Header().Set("Access-Control-Allow-Credentials", "true")
+					resp.Header().Set("Access-Control-Expose-Headers", "ETag, Location")
 
 					if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
 						resp.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						resp.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						resp.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
 						resp.WriteHeader(http.StatusOK)
 
 						return
@@ -231,12 +280,28 @@ func (metricsResp *metricsResponseWriter) Unwrap() http.ResponseWriter {
 	return metricsResp.wrapped
 }
 
+// publishedInt and publishedMap return the named expvar, publishing it on
+// first use, so the routes can be built more than once in a process.
+func publishedInt(name string) *expvar.Int {
+	if v, ok := expvar.Get(name).(*expvar.Int); ok {
+		return v
+	}
+	return expvar.NewInt(name)
+}
+
+func publishedMap(name string) *expvar.Map {
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v
+	}
+	return expvar.NewMap(name)
+}
+
 func (app *application) metrics(next http.Handler) http.Handler {
 	var (
-		totalRequestReceived            = expvar.NewInt("total_request_received")
-		totalResponsesSent              = expvar.NewInt("total_responses_sent")
-		totalProcessingTimeMicroseconds = expvar.NewInt("total_processing_time_μs")
-		totalResponsesSentByStatus      = expvar.NewMap("total_responses_sent_by_status")
+		totalRequestReceived            = publishedInt("total_request_received")
+		totalResponsesSent              = publishedInt("total_responses_sent")
+		totalProcessingTimeMicroseconds = publishedInt("total_processing_time_μs")
+		totalResponsesSentByStatus      = publishedMap("total_responses_sent_by_status")
 	)
 
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
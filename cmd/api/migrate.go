@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"greenlight/internal/migrate"
+	"log/slog"
+	"strconv"
+)
+
+func runMigrateCommand(logger *slog.Logger, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: api [flags] migrate up [N] | down [N] | status | force V")
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up", "down":
+		n, err := optionalCount(args[1:])
+		if err != nil {
+			return err
+		}
+
+		run := migrator.Up
+		if args[0] == "down" {
+			run = migrator.Down
+			n = max(n, 1)
+		}
+
+		applied, err := run(ctx, n)
+		for _, m := range applied {
+			logger.Info("migrated "+args[0], "version", m.Version, "name", m.Name)
+		}
+		if errors.Is(err, migrate.ErrNoChange) {
+			logger.Info("no migrations to apply")
+			return nil
+		}
+		return err
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range status.Migrations {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d\t%s\t%s\n", m.Version, state, m.Name)
+		}
+		fmt.Printf("current version: %d (dirty: %t), latest embedded version: %d\n", status.Version, status.Dirty, migrator.Latest())
+		return nil
+
+	case "force":
+		if len(args) != 2 {
+			return errors.New("usage: api [flags] migrate force V")
+		}
+
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		err = migrator.Force(ctx, v)
+		if err != nil {
+			return err
+		}
+		logger.Info("forced migration version", "version", v)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func prepareSchema(logger *slog.Logger, migrator *migrate.Migrator, autoMigrate bool) error {
+	ctx := context.Background()
+
+	if autoMigrate {
+		applied, err := migrator.Up(ctx, 0)
+		for _, m := range applied {
+			logger.Info("migrated up", "version", m.Version, "name", m.Name)
+		}
+		if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+	}
+
+	err := migrator.Check(ctx)
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Version < migrator.Latest() {
+		logger.Warn("database has pending migrations", "version", status.Version, "latest", migrator.Latest())
+	}
+
+	return nil
+}
+
+func optionalCount(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid migration count %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New("too many arguments")
+	}
+}
@@ -6,14 +6,16 @@ import (
 	"greenlight/internal/data"
 	"greenlight/internal/validator"
 	"net/http"
+	"net/url"
+	"slices"
 )
 
 func (app *application) createMovieHandler(resp http.ResponseWriter, req *http.Request) {
 	var input struct {
-		Title   string   `json:"title"`
-		Year    int32    `json:"year"`
-		Runtime string   `json:"runtime"`
-		Genres  []string `json:"genres"`
+		Title   string       `json:"title"`
+		Year    int32        `json:"year"`
+		Runtime data.Runtime `json:"runtime"`
+		Genres  []string     `json:"genres"`
 	}
 
 	err := app.readJSON(resp, req, &input)
@@ -29,20 +31,42 @@ func (app *application) createMovieHandler(resp http.ResponseWriter, req *http.R
 		Genres:  input.Genres,
 	}
 
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
 	v := validator.New()
-	if data.ValidateMovie(v, movie); !v.Valid() {
+	if data.ValidateMovie(v, movie, vocabulary); !v.Valid() {
 		app.failedValidationResponse(resp, req, v.Errors)
 		return
 	}
 
-	err = app.models.Movies.Insert(movie)
+	user := app.contextGetUser(req)
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Movies.Insert(req.Context(), movie)
+		if err != nil {
+			return err
+		}
+
+		return tx.Revisions.Insert(req.Context(), data.NewMovieRevision(movie, data.RevisionCreate, user.ID))
+	})
 	if err != nil {
-		app.serverErrorResponse(resp, req, err)
+		switch {
+		case errors.Is(err, data.ErrUnknownGenre):
+			v.AddError("genres", "contains a genre that no longer exists")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
 		return
 	}
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%d", movie.ID))
+	headers.Set("ETag", movieETag(movie))
 
 	err = app.writeJSON(resp, http.StatusCreated, envelope{"movie": movie}, headers)
 	if err != nil {
@@ -57,7 +81,7 @@ func (app *application) showMovieHandler(resp http.ResponseWriter, req *http.Req
 		return
 	}
 
-	movie, err := app.models.Movies.Get(id)
+	movie, err := app.models.Movies.Get(req.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -69,7 +93,34 @@ func (app *application) showMovieHandler(resp http.ResponseWriter, req *http.Req
 		return
 	}
 
-	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, nil)
+	include := app.readCVS(req.URL.Query(), "include", []string{})
+
+	v := validator.New()
+	for _, value := range include {
+		v.Check(validator.PermittedValue(value, "credits"), "include", "must only contain credits")
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	if slices.Contains(include, "credits") {
+		movie.Credits, err = app.models.Credits.GetAllForMovie(req.Context(), movie.ID)
+		if err != nil {
+			app.serverErrorResponse(resp, req, err)
+			return
+		}
+	}
+
+	etag := movieETag(movie)
+	if app.notModified(resp, req, etag) {
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 	}
@@ -82,7 +133,7 @@ func (app *application) updateMovieHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
-	movie, err := app.models.Movies.Get(id)
+	movie, err := app.models.Movies.Get(req.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -94,11 +145,15 @@ func (app *application) updateMovieHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
+	if !app.checkMovieIfMatch(resp, req, movie) {
+		return
+	}
+
 	var input struct {
-		Title   *string  `json:"title"`
-		Year    *int32   `json:"year"`
-		Runtime *string  `json:"runtime"`
-		Genres  []string `json:"genres"`
+		Title   *string       `json:"title"`
+		Year    *int32        `json:"year"`
+		Runtime *data.Runtime `json:"runtime"`
+		Genres  []string      `json:"genres"`
 	}
 
 	err = app.readJSON(resp, req, &input)
@@ -120,17 +175,35 @@ func (app *application) updateMovieHandler(resp http.ResponseWriter, req *http.R
 		movie.Genres = input.Genres
 	}
 
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
 	v := validator.New()
-	if data.ValidateMovie(v, movie); !v.Valid() {
+	if data.ValidateMovie(v, movie, vocabulary); !v.Valid() {
 		app.failedValidationResponse(resp, req, v.Errors)
 		return
 	}
 
-	err = app.models.Movies.Update(movie)
+	user := app.contextGetUser(req)
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Movies.Update(req.Context(), movie)
+		if err != nil {
+			return err
+		}
+
+		return tx.Revisions.Insert(req.Context(), data.NewMovieRevision(movie, data.RevisionUpdate, user.ID))
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
 			app.editConflictResponse(resp, req)
+		case errors.Is(err, data.ErrUnknownGenre):
+			v.AddError("genres", "contains a genre that no longer exists")
+			app.failedValidationResponse(resp, req, v.Errors)
 		default:
 			app.serverErrorResponse(resp, req, err)
 		}
@@ -138,7 +211,10 @@ func (app *application) updateMovieHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
-	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, nil)
+	headers := make(http.Header)
+	headers.Set("ETag", movieETag(movie))
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 	}
@@ -151,7 +227,7 @@ func (app *application) deleteMovieHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
-	err = app.models.Movies.Delete(id)
+	movie, err := app.models.Movies.Get(req.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -163,7 +239,32 @@ func (app *application) deleteMovieHandler(resp http.ResponseWriter, req *http.R
 		return
 	}
 
-	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "The movie successfully deleted."}, nil)
+	if !app.checkMovieIfMatch(resp, req, movie) {
+		return
+	}
+
+	user := app.contextGetUser(req)
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Movies.Delete(req.Context(), movie)
+		if err != nil {
+			return err
+		}
+
+		return tx.Revisions.Insert(req.Context(), data.NewMovieRevision(movie, data.RevisionDelete, user.ID))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "The movie successfully moved to trash."}, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 	}
@@ -171,27 +272,89 @@ func (app *application) deleteMovieHandler(resp http.ResponseWriter, req *http.R
 
 func (app *application) listMovieHandler(resp http.ResponseWriter, req *http.Request) {
 	var input struct {
-		Title  string
-		Genres []string
+		data.MovieFilter
 		data.Filters
 	}
 
 	v := validator.New()
 	qs := req.URL.Query()
 
-	input.Title = app.readString(qs, "title", "")
-	input.Genres = app.readCVS(qs, "genres", []string{})
+	input.MovieFilter = app.readMovieFilter(qs, v)
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Filters.Sort = app.readString(qs, "sort", "id")
-	input.Filters.SortSafeList = []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"}
+	input.Filters.SortSafeList = []string{"id", "title", "year", "runtime", "rating", "relevance", "-id", "-title", "-year", "-runtime", "-rating"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.CursorSecret = app.config.cursorSecret
+	input.Filters.IncludeTotal = app.readBool(qs, "include_total", input.Filters.Cursor == "", v)
+
+	v.Check(input.Sort != "relevance" || input.Title != "", "sort", "relevance requires a title search")
+	v.Check(input.Sort != "relevance" || input.Cursor == "", "cursor", "must not be combined with relevance sort")
+
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	data.ValidateMovieFilter(v, &input.MovieFilter, vocabulary)
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	movies, metadata, err := app.models.Movies.GetAll(req.Context(), input.MovieFilter, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"movies": movies, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) readMovieFilter(qs url.Values, v *validator.Validator) data.MovieFilter {
+	return data.MovieFilter{
+		Title:          app.readString(qs, "title", ""),
+		SearchLanguage: app.config.searchLanguage,
+		Genres:         app.readCVS(qs, "genres", []string{}),
+		GenresAny:      app.readCVS(qs, "genres_any", []string{}),
+		GenresNone:     app.readCVS(qs, "genres_none", []string{}),
+		YearFrom:       app.readInt(qs, "year_from", 0, v),
+		YearTo:         app.readInt(qs, "year_to", 0, v),
+		RuntimeMin:     app.readRuntime(qs, "runtime_min", v),
+		RuntimeMax:     app.readRuntime(qs, "runtime_max", v),
+		CreatedAfter:   app.readTime(qs, "created_after", v),
+		CreatedBefore:  app.readTime(qs, "created_before", v),
+		PersonID:       int64(app.readInt(qs, "person_id", 0, v)),
+		Match:          app.readString(qs, "match", data.MatchAll),
+	}
+}
+
+func (app *application) listDeletedMoviesHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-deleted_at")
+	input.Filters.SortSafeList = []string{"id", "title", "deleted_at", "-id", "-title", "-deleted_at"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.CursorSecret = app.config.cursorSecret
+	input.Filters.IncludeTotal = app.readBool(qs, "include_total", input.Filters.Cursor == "", v)
 
 	if data.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(resp, req, v.Errors)
 		return
 	}
 
-	movies, metadata, err := app.models.Movies.GetAll(input.Title, input.Genres, input.Filters)
+	movies, metadata, err := app.models.Movies.GetAllDeleted(req.Context(), input.Filters)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 		return
@@ -202,3 +365,69 @@ func (app *application) listMovieHandler(resp http.ResponseWriter, req *http.Req
 		app.serverErrorResponse(resp, req, err)
 	}
 }
+
+func (app *application) restoreMovieHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	user := app.contextGetUser(req)
+
+	var movie *data.Movie
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		var err error
+		movie, err = tx.Movies.Restore(req.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Revisions.Insert(req.Context(), data.NewMovieRevision(movie, data.RevisionRestore, user.ID))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", movieETag(movie))
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) purgeMovieHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	err = app.models.Movies.Purge(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	app.deletePosters(req.Context(), id)
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "The movie permanently deleted."}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"greenlight/internal/data"
+)
+
+func TestShowMovieWithCreditsETag(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	movie := insertTestMovie(t, app, "Moana")
+	path := fmt.Sprintf("/v1/movies/%d", movie.ID)
+
+	person := &data.Person{Name: "Auli'i Cravalho"}
+	err := app.models.People.Insert(context.Background(), person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, token := insertTestUser(t, app, "alice@example.com", "movies:read", "movies:write")
+
+	get := func(path, ifNoneMatch string) (int, string) {
+		t.Helper()
+
+		status, headers, _ := ts.do(t, http.MethodGet, path, token, "", "If-None-Match", ifNoneMatch)
+		return status, headers.Get("ETag")
+	}
+
+	_, plain := get(path, "")
+	status, withCredits := get(path+"?include=credits", "")
+	if status != http.StatusOK || withCredits == "" {
+		t.Fatalf("got status %d and ETag %q with credits; want %d and an ETag", status, withCredits, http.StatusOK)
+	}
+
+	status, _ = get(path+"?include=credits", withCredits)
+	if status != http.StatusNotModified {
+		t.Errorf("got status %d for current ETag; want %d", status, http.StatusNotModified)
+	}
+
+	status, _, body := ts.do(t, http.MethodPost, path+"/credits", token, fmt.Sprintf(`{"person_id": %d, "role": "cast", "character": "Moana"}`, person.ID))
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d adding credit; want %d: %s", status, http.StatusCreated, body)
+	}
+
+	// Credits don't bump the movie version, so only the representation that
+	// includes them changes.
+	status, _ = get(path, plain)
+	if status != http.StatusNotModified {
+		t.Errorf("got status %d without credits; want %d", status, http.StatusNotModified)
+	}
+
+	status, updated := get(path+"?include=credits", withCredits)
+	if status != http.StatusOK || updated == withCredits {
+		t.Errorf("got status %d and ETag %q with credits; want %d and a new ETag", status, updated, http.StatusOK)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"greenlight/internal/storage"
+	"greenlight/internal/validator"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	posterOriginal  = "original"
+	posterMaxPixels = 40_000_000
+)
+
+// posterWidths are the widths of the JPEG thumbnails generated for every
+// poster. Images narrower than a size are not scaled up.
+var posterWidths = map[string]int{
+	"small":  185,
+	"medium": 342,
+	"large":  780,
+}
+
+var posterTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+func posterKey(movieID int64, size string) string {
+	return fmt.Sprintf("posters/%d/%s", movieID, size)
+}
+
+func (app *application) showMoviePosterHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+
+	size := app.readString(req.URL.Query(), "size", posterOriginal)
+	if v.Check(size == posterOriginal || posterWidths[size] > 0, "size", "must be original, small, medium or large"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	blob, err := app.blobs.Get(req.Context(), posterKey(movieID, size))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+	defer blob.Close()
+
+	// The content type is sniffed by ServeContent, which also answers
+	// conditional and range requests from the ETag and modification time.
+	resp.Header().Set("Cache-Control", "private, max-age=3600")
+	resp.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, blob.ModTime.UnixNano(), blob.Size))
+
+	http.ServeContent(resp, req, "", blob.ModTime, blob)
+}
+
+func (app *application) updateMoviePosterHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	req.Body = http.MaxBytesReader(resp, req.Body, app.config.posters.maxBytes)
+
+	var body io.Reader = req.Body
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		part, err := readPosterPart(req)
+		if err != nil {
+			app.badRequestResponse(resp, req, err)
+			return
+		}
+		body = part
+	}
+
+	original, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			err = fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		}
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	contentType := http.DetectContentType(original)
+	if !slices.Contains(posterTypes, contentType) {
+		app.unsupportedMediaTypeResponse(resp, req, posterTypes...)
+		return
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		app.badRequestResponse(resp, req, fmt.Errorf("body contains an invalid image: %w", err))
+		return
+	}
+
+	v := validator.New()
+	if v.Check(config.Width*config.Height <= posterMaxPixels, "poster", "must not be larger than 40 megapixels"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	thumbnails, err := posterThumbnails(original)
+	if err != nil {
+		app.badRequestResponse(resp, req, fmt.Errorf("body contains an invalid image: %w", err))
+		return
+	}
+
+	err = app.savePoster(req.Context(), movieID, original, thumbnails)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	poster := envelope{
+		"content_type": contentType,
+		"width":        config.Width,
+		"height":       config.Height,
+		"size":         len(original),
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%d/poster", movieID))
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"poster": poster}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// savePoster stores the thumbnails before the original, so a poster whose
+// original is visible always has a complete set of sizes.
+func (app *application) savePoster(ctx context.Context, movieID int64, original []byte, thumbnails map[string][]byte) error {
+	for size, thumbnail := range thumbnails {
+		err := app.blobs.Put(ctx, posterKey(movieID, size), bytes.NewReader(thumbnail))
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.blobs.Put(ctx, posterKey(movieID, posterOriginal), bytes.NewReader(original))
+}
+
+// deletePosters removes the stored posters of purged movies. Failures are
+// only logged, as the movies themselves are already gone.
+func (app *application) deletePosters(ctx context.Context, movieIDs ...int64) {
+	for _, id := range movieIDs {
+		err := app.blobs.DeleteAll(ctx, fmt.Sprintf("posters/%d", id))
+		if err != nil {
+			app.logger.Error(err.Error(), "movie_id", id)
+		}
+	}
+}
+
+// readPosterPart returns the "poster" file of a multipart upload.
+func readPosterPart(req *http.Request) (io.Reader, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New(`body must contain a "poster" file`)
+			}
+			return nil, err
+		}
+
+		if part.FormName() == "poster" {
+			return part, nil
+		}
+	}
+}
+
+func posterThumbnails(original []byte) (map[string][]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	thumbnails := make(map[string][]byte, len(posterWidths))
+
+	for size, width := range posterWidths {
+		width = min(width, bounds.Dx())
+		height := max(1, bounds.Dy()*width/bounds.Dx())
+
+		// JPEG has no alpha channel, so transparent areas are painted white.
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		if err != nil {
+			return nil, err
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+
+	return thumbnails, nil
+}
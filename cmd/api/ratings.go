@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) showMovieRatingHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	rating, err := app.models.Ratings.Get(req.Context(), movieID, app.contextGetUser(req).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"rating": rating}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) createMovieRatingHandler(resp http.ResponseWriter, req *http.Request) {
+	app.saveMovieRating(resp, req, false)
+}
+
+func (app *application) updateMovieRatingHandler(resp http.ResponseWriter, req *http.Request) {
+	app.saveMovieRating(resp, req, true)
+}
+
+// saveMovieRating records the score of the current user for a movie. POST only
+// creates a rating, while PUT also replaces an existing one.
+func (app *application) saveMovieRating(resp http.ResponseWriter, req *http.Request, replace bool) {
+	movieID, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	var input struct {
+		Score int32 `json:"score"`
+	}
+
+	err = app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	rating := &data.Rating{
+		MovieID: movieID,
+		UserID:  app.contextGetUser(req).ID,
+		Score:   input.Score,
+	}
+
+	v := validator.New()
+	if data.ValidateRating(v, rating); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	status := http.StatusCreated
+	if replace {
+		status = http.StatusOK
+		err = app.models.Ratings.Set(req.Context(), rating)
+	} else {
+		err = app.models.Ratings.Insert(req.Context(), rating)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		case errors.Is(err, data.ErrDuplicateRating):
+			v.AddError("score", "you have already rated this movie, use PUT to change it")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, status, envelope{"rating": rating}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) deleteMovieRatingHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	err = app.models.Ratings.Delete(req.Context(), movieID, app.contextGetUser(req).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "rating successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
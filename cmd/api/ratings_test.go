@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMovieRatingAggregates(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	movie := insertTestMovie(t, app, "Moana")
+	path := fmt.Sprintf("/v1/movies/%d", movie.ID)
+
+	_, alice := insertTestUser(t, app, "alice@example.com", "movies:read")
+	_, bob := insertTestUser(t, app, "bob@example.com", "movies:read")
+
+	type movieResponse struct {
+		Movie struct {
+			AverageRating float64 `json:"average_rating"`
+			RatingCount   int32   `json:"rating_count"`
+		} `json:"movie"`
+	}
+
+	_, headers, _ := ts.do(t, http.MethodGet, path, alice, "")
+	staleETag := headers.Get("ETag")
+
+	steps := []struct {
+		name        string
+		token       string
+		method      string
+		body        string
+		wantStatus  int
+		wantAverage float64
+		wantCount   int32
+	}{
+		{"first rating", alice, http.MethodPost, `{"score": 8}`, http.StatusCreated, 8, 1},
+		{"second rating", bob, http.MethodPost, `{"score": 5}`, http.StatusCreated, 6.5, 2},
+		{"duplicate rating", bob, http.MethodPost, `{"score": 9}`, http.StatusUnprocessableEntity, 6.5, 2},
+		{"replaced rating", bob, http.MethodPut, `{"score": 10}`, http.StatusOK, 9, 2},
+		{"deleted rating", alice, http.MethodDelete, ``, http.StatusOK, 10, 1},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			status, _, body := ts.do(t, step.method, path+"/ratings", step.token, step.body)
+			if status != step.wantStatus {
+				t.Fatalf("got status %d; want %d: %s", status, step.wantStatus, body)
+			}
+
+			// A rejected change keeps the cached copy valid, an accepted one must not.
+			wantStatus := http.StatusOK
+			if step.wantStatus >= http.StatusBadRequest {
+				wantStatus = http.StatusNotModified
+			}
+
+			status, headers, body := ts.do(t, http.MethodGet, path, alice, "", "If-None-Match", staleETag)
+			if status != wantStatus {
+				t.Fatalf("got status %d for ETag %s; want %d", status, staleETag, wantStatus)
+			}
+			if status == http.StatusNotModified {
+				return
+			}
+			staleETag = headers.Get("ETag")
+
+			var got movieResponse
+			decodeTestJSON(t, body, &got)
+
+			if got.Movie.AverageRating != step.wantAverage || got.Movie.RatingCount != step.wantCount {
+				t.Errorf("got average %v over %d ratings; want %v over %d", got.Movie.AverageRating, got.Movie.RatingCount, step.wantAverage, step.wantCount)
+			}
+		})
+	}
+
+	status, _, _ := ts.do(t, http.MethodGet, path, alice, "", "If-None-Match", staleETag)
+	if status != http.StatusNotModified {
+		t.Errorf("got status %d for current ETag; want %d", status, http.StatusNotModified)
+	}
+}
+
+func TestMovieIfMatchIgnoresRatings(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	movie := insertTestMovie(t, app, "Moana")
+	path := fmt.Sprintf("/v1/movies/%d", movie.ID)
+
+	_, editor := insertTestUser(t, app, "editor@example.com", "movies:read", "movies:write")
+	_, viewer := insertTestUser(t, app, "viewer@example.com", "movies:read")
+
+	_, headers, _ := ts.do(t, http.MethodGet, path, editor, "")
+	etag := headers.Get("ETag")
+
+	status, _, body := ts.do(t, http.MethodPost, path+"/ratings", viewer, `{"score": 7}`)
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d rating; want %d: %s", status, http.StatusCreated, body)
+	}
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{"stale movie ETag", fmt.Sprintf(`W/"%d-0-0-0"`, movie.ID), http.StatusPreconditionFailed},
+		{"movie ETag from before the rating", etag, http.StatusOK},
+		{"movie ETag from before the edit", etag, http.StatusPreconditionFailed},
+		{"strong validator", fmt.Sprintf(`"%d-%d"`, movie.ID, movie.Version+1), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, body := ts.do(t, http.MethodPatch, path, editor, `{"title": "Moana"}`, "If-Match", tt.ifMatch)
+			if status != tt.wantStatus {
+				t.Errorf("got status %d; want %d: %s", status, tt.wantStatus, body)
+			}
+		})
+	}
+}
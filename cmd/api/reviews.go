@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) listMovieReviewsHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	input.Filters.SortSafeList = []string{"created_at", "-created_at"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	moderator, err := app.isReviewModerator(req)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	reviews, metadata, err := app.models.Reviews.GetAllForMovie(req.Context(), movieID, moderator, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"reviews": reviews, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) createMovieReviewHandler(resp http.ResponseWriter, req *http.Request) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Body string `json:"body"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	review := &data.Review{
+		MovieID: movieID,
+		UserID:  app.contextGetUser(req).ID,
+		Body:    input.Body,
+	}
+
+	v := validator.New()
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Reviews.Insert(req.Context(), review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReview):
+			v.AddError("body", "you have already reviewed this movie")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%d/reviews/%d", movieID, review.ID))
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"review": review}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showMovieReviewHandler(resp http.ResponseWriter, req *http.Request) {
+	review, ok := app.readReview(resp, req)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(resp, http.StatusOK, envelope{"review": review}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) updateMovieReviewHandler(resp http.ResponseWriter, req *http.Request) {
+	review, ok := app.readReview(resp, req)
+	if !ok {
+		return
+	}
+
+	if review.UserID != app.contextGetUser(req).ID {
+		app.notPermittedResponse(resp, req)
+		return
+	}
+
+	var input struct {
+		Body *string `json:"body"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.Body != nil {
+		review.Body = *input.Body
+	}
+
+	v := validator.New()
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	app.saveMovieReview(resp, req, review)
+}
+
+func (app *application) hideMovieReviewHandler(resp http.ResponseWriter, req *http.Request) {
+	review, ok := app.readReview(resp, req)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Hidden *bool `json:"hidden"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.Hidden != nil, "hidden", "must be provided"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	review.Hidden = *input.Hidden
+
+	app.saveMovieReview(resp, req, review)
+}
+
+func (app *application) deleteMovieReviewHandler(resp http.ResponseWriter, req *http.Request) {
+	review, ok := app.readReview(resp, req)
+	if !ok {
+		return
+	}
+
+	if review.UserID != app.contextGetUser(req).ID {
+		moderator, err := app.isReviewModerator(req)
+		if err != nil {
+			app.serverErrorResponse(resp, req, err)
+			return
+		}
+		if !moderator {
+			app.notPermittedResponse(resp, req)
+			return
+		}
+	}
+
+	err := app.models.Reviews.Delete(req.Context(), review.MovieID, review.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "review successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) saveMovieReview(resp http.ResponseWriter, req *http.Request, review *data.Review) {
+	err := app.models.Reviews.Update(req.Context(), review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"review": review}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// readReview loads the review in the URL. Hidden reviews are only visible to
+// their author and to moderators.
+func (app *application) readReview(resp http.ResponseWriter, req *http.Request) (*data.Review, bool) {
+	movieID, ok := app.readMovieID(resp, req)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := app.readReviewIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return nil, false
+	}
+
+	review, err := app.models.Reviews.Get(req.Context(), movieID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return nil, false
+	}
+
+	if review.Hidden && review.UserID != app.contextGetUser(req).ID {
+		moderator, err := app.isReviewModerator(req)
+		if err != nil {
+			app.serverErrorResponse(resp, req, err)
+			return nil, false
+		}
+		if !moderator {
+			app.notFoundErrorRespone(resp, req)
+			return nil, false
+		}
+	}
+
+	return review, true
+}
+
+func (app *application) isReviewModerator(req *http.Request) (bool, error) {
+	permissions, err := app.models.Permissions.GetAllForUser(req.Context(), app.contextGetUser(req).ID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Include("reviews:moderate"), nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"greenlight/internal/data"
+	"greenlight/internal/validator"
+	"net/http"
+)
+
+func (app *application) listMovieRevisionsHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-version")
+	input.Filters.SortSafeList = []string{"version", "-version"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	revisions, metadata, err := app.models.Revisions.GetAllForMovie(req.Context(), id, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if len(revisions) == 0 && input.Filters.Page == 1 {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"revisions": revisions, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showMovieRevisionHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	version, err := app.readVersionParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(req.Context(), id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"revision": revision}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) diffMovieRevisionsHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	v := validator.New()
+	qs := req.URL.Query()
+
+	from := app.readInt(qs, "from", 0, v)
+	to := app.readInt(qs, "to", 0, v)
+
+	v.Check(from > 0, "from", "must be a positive version")
+	v.Check(to > 0, "to", "must be a positive version")
+
+	if !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	revisions := make([]*data.MovieRevision, 0, 2)
+	for _, version := range []int{from, to} {
+		revision, err := app.models.Revisions.Get(req.Context(), id, int32(version))
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundErrorRespone(resp, req)
+			default:
+				app.serverErrorResponse(resp, req, err)
+			}
+
+			return
+		}
+		revisions = append(revisions, revision)
+	}
+
+	diff := envelope{
+		"from":    revisions[0].Version,
+		"to":      revisions[1].Version,
+		"changes": data.DiffRevisions(revisions[0], revisions[1]),
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"diff": diff}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) revertMovieHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	var input struct {
+		Version int32 `json:"version"`
+	}
+
+	err = app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.Version > 0, "version", "must be a positive version"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movies.Get(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	if !app.checkMovieIfMatch(resp, req, movie) {
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(req.Context(), id, input.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("version", "does not exist for this movie")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	movie.Title = revision.Title
+	movie.Year = revision.Year
+	movie.Runtime = revision.Runtime
+	movie.Genres = revision.Genres
+
+	vocabulary, err := app.genreVocabulary(req.Context())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if data.ValidateMovie(v, movie, vocabulary); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(req)
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Movies.Update(req.Context(), movie)
+		if err != nil {
+			return err
+		}
+
+		return tx.Revisions.Insert(req.Context(), data.NewMovieRevision(movie, data.RevisionRevert, user.ID))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		case errors.Is(err, data.ErrUnknownGenre):
+			v.AddError("genres", "contains a genre that no longer exists")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", movieETag(movie))
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"movie": movie}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
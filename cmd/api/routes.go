@@ -17,14 +17,83 @@ func (app *application) routes() http.Handler {
 
 	router.HandlerFunc(http.MethodGet, "/v1/movies", app.requirePermission("movies:read", app.listMovieHandler))
 	router.HandlerFunc(http.MethodPost, "/v1/movies", app.requirePermission("movies:write", app.createMovieHandler))
-	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.requirePermission("movies:read", app.showMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id", app.staticOrParam(map[string]http.HandlerFunc{
+		"import": app.requirePermission("movies:write", app.importMoviesHandler),
+	}, app.methodNotAllowedErrorResponse))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.staticOrParam(map[string]http.HandlerFunc{
+		"trash":   app.requirePermission("movies:write", app.listDeletedMoviesHandler),
+		"export":  app.requirePermission("movies:export", app.exportMoviesHandler),
+		"suggest": app.requirePermission("movies:read", app.suggestMoviesHandler),
+		"facets":  app.requirePermission("movies:read", app.movieFacetsHandler),
+	}, app.requirePermission("movies:read", app.showMovieHandler)))
 	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id", app.requirePermission("movies:write", app.updateMovieHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id", app.requirePermission("movies:write", app.deleteMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/restore", app.requirePermission("movies:write", app.restoreMovieHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/purge", app.requirePermission("movies:purge", app.purgeMovieHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/poster", app.requirePermission("movies:read", app.showMoviePosterHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/poster", app.requirePermission("movies:write", app.updateMoviePosterHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions", app.requirePermission("movies:read", app.listMovieRevisionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions/:version", app.requirePermission("movies:read", app.showMovieRevisionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/diff", app.requirePermission("movies:read", app.diffMovieRevisionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/revert", app.requirePermission("movies:write", app.revertMovieHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/credits", app.requirePermission("movies:read", app.listMovieCreditsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/credits", app.requirePermission("movies:write", app.createMovieCreditHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/credits/:credit_id", app.requirePermission("movies:read", app.showMovieCreditHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id/credits/:credit_id", app.requirePermission("movies:write", app.updateMovieCreditHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/credits/:credit_id", app.requirePermission("movies:write", app.deleteMovieCreditHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/ratings", app.requirePermission("movies:read", app.showMovieRatingHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/ratings", app.requirePermission("movies:read", app.createMovieRatingHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/ratings", app.requirePermission("movies:read", app.updateMovieRatingHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/ratings", app.requirePermission("movies:read", app.deleteMovieRatingHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.listMovieReviewsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.createMovieReviewHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews/:review_id", app.requirePermission("movies:read", app.showMovieReviewHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id/reviews/:review_id", app.requirePermission("movies:read", app.updateMovieReviewHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/reviews/:review_id", app.requirePermission("movies:read", app.deleteMovieReviewHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/reviews/:review_id/hidden", app.requirePermission("reviews:moderate", app.hideMovieReviewHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/people", app.requirePermission("movies:read", app.listPeopleHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/people", app.requirePermission("movies:write", app.createPersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id", app.requirePermission("movies:read", app.showPersonHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/people/:id", app.requirePermission("movies:write", app.updatePersonHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/people/:id", app.requirePermission("movies:write", app.deletePersonHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/genres", app.requirePermission("movies:read", app.listGenresHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/genres", app.requirePermission("genres:write", app.createGenreHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/genres/:slug", app.requirePermission("movies:read", app.showGenreHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/genres/:slug", app.requirePermission("genres:write", app.updateGenreHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/genres/:slug", app.requirePermission("genres:write", app.deleteGenreHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/collections", app.listPublicCollectionsHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/collections/:id", app.showSharedCollectionHandler)
 
 	router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.activateUserHandler)
 
+	router.HandlerFunc(http.MethodGet, "/v1/users/me", app.requireActivatedUser(app.showCurrentUserHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me", app.requireActivatedUser(app.updateCurrentUserHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me", app.requireActivatedUser(app.deleteCurrentUserHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/email", app.requireActivatedUser(app.requestEmailChangeHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/users/me/email", app.requireActivatedUser(app.confirmEmailChangeHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/permissions", app.requireActivatedUser(app.listCurrentUserPermissionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/sessions", app.requireActivatedUser(app.listSessionsHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/sessions/:id", app.requireActivatedUser(app.deleteSessionHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/collections", app.requireActivatedUser(app.listCollectionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/collections", app.requireActivatedUser(app.createCollectionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/collections/:id", app.requireActivatedUser(app.showCollectionHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me/collections/:id", app.requireActivatedUser(app.updateCollectionHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/collections/:id", app.requireActivatedUser(app.deleteCollectionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/collections/:id/items", app.requireActivatedUser(app.addCollectionItemHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/collections/:id/items/:movie_id", app.requireActivatedUser(app.showCollectionItemHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me/collections/:id/items/:movie_id", app.requireActivatedUser(app.updateCollectionItemHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/collections/:id/items/:movie_id", app.requireActivatedUser(app.removeCollectionItemHandler))
+
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", app.refreshAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication", app.requireAuthenticatedUser(app.deleteAuthenticationTokenHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication/all", app.requireAuthenticatedUser(app.deleteAllAuthenticationTokensHandler))
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/activation", app.createActivationTokenHandler)
 
 	router.HandlerFunc(http.MethodPut, "/v1/users/password", app.updateUserPasswordHandler)
@@ -34,3 +103,17 @@ func (app *application) routes() http.Handler {
 
 	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
 }
+
+// staticOrParam lets fixed segments such as /v1/movies/trash share a position
+// with the :id wildcard, which httprouter does not allow in a single tree.
+func (app *application) staticOrParam(static map[string]http.HandlerFunc, param http.HandlerFunc) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		params := httprouter.ParamsFromContext(req.Context())
+		if handler, ok := static[params.ByName("id")]; ok {
+			handler(resp, req)
+			return
+		}
+
+		param(resp, req)
+	}
+}
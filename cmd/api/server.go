@@ -24,6 +24,11 @@ func (app *application) startServer() error {
 
 	shutdownError := make(chan error)
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	app.purgeDeletedMovies(jobsCtx)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -40,6 +45,8 @@ func (app *application) startServer() error {
 
 		app.logger.Info("completing background tasks", "port", server.Addr)
 
+		stopJobs()
+
 		app.wg.Wait()
 		shutdownError <- nil
 	}()
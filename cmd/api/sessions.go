@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"greenlight/internal/data"
+	"net/http"
+	"time"
+)
+
+// sessionTouchInterval is how stale a token's last-used time may get before
+// authenticate records a new one.
+const sessionTouchInterval = 5 * time.Minute
+
+func (app *application) listSessionsHandler(resp http.ResponseWriter, req *http.Request) {
+	token, _ := bearerToken(req.Header.Get("Authorization"))
+
+	sessions, err := app.models.Tokens.GetSessionsForUser(req.Context(), app.contextGetUser(req).ID, data.HashToken(token))
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"sessions": sessions}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) deleteSessionHandler(resp http.ResponseWriter, req *http.Request) {
+	id, err := app.readIDParam(req)
+	if err != nil {
+		app.notFoundErrorRespone(resp, req)
+		return
+	}
+
+	err = app.models.Tokens.DeleteSession(req.Context(), app.contextGetUser(req).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "session successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
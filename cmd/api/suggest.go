@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"greenlight/internal/validator"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+func (app *application) suggestMoviesHandler(resp http.ResponseWriter, req *http.Request) {
+	v := validator.New()
+	qs := req.URL.Query()
+
+	q := strings.TrimSpace(app.readString(qs, "q", ""))
+	limit := app.readInt(qs, "limit", 10, v)
+
+	v.Check(q != "", "q", "must be provided")
+	v.Check(utf8.RuneCountInString(q) <= 100, "q", "must not be more than 100 characters long")
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 20, "limit", "must be a maximum of 20")
+
+	if !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	key := fmt.Sprintf("%d:%s", limit, strings.ToLower(q))
+
+	suggestions, ok := app.suggestions.get(key)
+	if !ok {
+		var err error
+		suggestions, err = app.models.Movies.Suggest(req.Context(), q, limit)
+		if err != nil {
+			app.serverErrorResponse(resp, req, err)
+			return
+		}
+		app.suggestions.set(key, suggestions)
+	}
+
+	headers := make(http.Header)
+	if app.config.suggestTTL > 0 {
+		headers.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(app.config.suggestTTL.Seconds())))
+	}
+
+	err := app.writeJSON(resp, http.StatusOK, envelope{"suggestions": suggestions}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"greenlight/internal/data"
+	"greenlight/internal/storage"
+)
+
+const testPassword = "pa55word123"
+
+// newTestApplication returns an application backed by the in-memory models
+// that discards its logs.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	var cfg config
+	cfg.cursorSecret = []byte("secret")
+	cfg.posters.maxBytes = 1 << 20
+	cfg.moviesImport.maxBytes = 1 << 20
+	cfg.moviesImport.batchSize = 100
+	cfg.tokens.accessTTL = 15 * time.Minute
+	cfg.tokens.refreshTTL = time.Hour
+
+	blobs, err := storage.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &application{
+		config:      cfg,
+		models:      data.NewMemoryModels(),
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		blobs:       blobs,
+		suggestions: newTTLCache[[]*data.MovieSuggestion](time.Minute, 10),
+		genres:      newTTLCache[data.GenreVocabulary](time.Minute, 1),
+	}
+}
+
+type testServer struct {
+	*httptest.Server
+}
+
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	return &testServer{ts}
+}
+
+// do sends a request with body, authenticated with token unless it is empty,
+// and returns the response status, headers and body.
+func (ts *testServer) do(t *testing.T, method, path, token, body string, headers ...string) (int, http.Header, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header, string(respBody)
+}
+
+// insertTestUser creates an activated user with the given permissions and
+// returns them along with an authentication token.
+func insertTestUser(t *testing.T, app *application, email string, permissions ...string) (*data.User, string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	user := &data.User{Name: "Test User", Email: email, Activated: true}
+	err := user.Password.Set(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = app.models.Users.Insert(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(permissions) > 0 {
+		err = app.models.Permissions.AddForUser(ctx, user.ID, permissions...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token, err := app.models.Tokens.New(ctx, user.ID, time.Hour, data.ScopeAuthentication)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user, token.Plaintext
+}
+
+func insertTestMovie(t *testing.T, app *application, title string) *data.Movie {
+	t.Helper()
+
+	movie := &data.Movie{Title: title, Year: 2016, Runtime: 107, Genres: []string{"animation"}}
+
+	err := app.models.Movies.Insert(context.Background(), movie)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return movie
+}
+
+func decodeTestJSON(t *testing.T, body string, dst any) {
+	t.Helper()
+
+	err := json.Unmarshal([]byte(body), dst)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"greenlight/internal/validator"
 	"net/http"
 	"time"
+
+	"github.com/tomasen/realip"
 )
 
 func (app *application) createAuthenticationTokenHandler(resp http.ResponseWriter, req *http.Request) {
@@ -30,7 +32,7 @@ func (app *application) createAuthenticationTokenHandler(resp http.ResponseWrite
 		return
 	}
 
-	user, err := app.models.Users.GetByEmail(input.Email)
+	user, err := app.models.Users.GetByEmail(req.Context(), input.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -52,13 +54,56 @@ func (app *application) createAuthenticationTokenHandler(resp http.ResponseWrite
 		return
 	}
 
-	token, err := app.models.Tokens.New(user.ID, 24*time.Hour, data.ScopeAuthentication)
+	access, refresh, err := app.models.Tokens.NewSession(req.Context(), user.ID, app.config.tokens.accessTTL, app.config.tokens.refreshTTL, realip.FromRequest(req), req.UserAgent())
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"authentication_token": access, "refresh_token": refresh}, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// refreshAuthenticationTokenHandler exchanges a refresh token for a new
+// authentication and refresh token. Each refresh token works once; using one
+// again ends the session it belongs to.
+func (app *application) refreshAuthenticationTokenHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, input.TokenPlaintext); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	access, refresh, err := app.models.Tokens.Rotate(req.Context(), input.TokenPlaintext, app.config.tokens.accessTTL, realip.FromRequest(req), req.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTokenReused):
+			app.logger.Warn("refresh token reused, session revoked", "ip", realip.FromRequest(req))
+			v.AddError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(resp, req, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
 		return
 	}
 
-	err = app.writeJSON(resp, http.StatusCreated, envelope{"authentication_token": token}, nil)
+	err = app.writeJSON(resp, http.StatusCreated, envelope{"authentication_token": access, "refresh_token": refresh}, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 	}
@@ -81,7 +126,7 @@ func (app *application) createActivationTokenHandler(resp http.ResponseWriter, r
 		return
 	}
 
-	user, err := app.models.Users.GetByEmail(input.Email)
+	user, err := app.models.Users.GetByEmail(req.Context(), input.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -99,7 +144,7 @@ func (app *application) createActivationTokenHandler(resp http.ResponseWriter, r
 		return
 	}
 
-	token, err := app.models.Tokens.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
+	token, err := app.models.Tokens.New(req.Context(), user.ID, 3*24*time.Hour, data.ScopeActivation)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 		return
@@ -142,7 +187,7 @@ func (app *application) createPasswordResetTokenHandler(resp http.ResponseWriter
 		return
 	}
 
-	user, err := app.models.Users.GetByEmail(input.Email)
+	user, err := app.models.Users.GetByEmail(req.Context(), input.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -160,7 +205,7 @@ func (app *application) createPasswordResetTokenHandler(resp http.ResponseWriter
 		return
 	}
 
-	token, err := app.models.Tokens.New(user.ID, 45*time.Minute, data.ScopePasswordReset)
+	token, err := app.models.Tokens.New(req.Context(), user.ID, 45*time.Minute, data.ScopePasswordReset)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 		return
@@ -184,3 +229,49 @@ func (app *application) createPasswordResetTokenHandler(resp http.ResponseWriter
 		app.serverErrorResponse(resp, req, err)
 	}
 }
+
+// deleteAuthenticationTokenHandler logs out by revoking the token the request
+// was authenticated with, along with the refresh token of its session.
+func (app *application) deleteAuthenticationTokenHandler(resp http.ResponseWriter, req *http.Request) {
+	token, _ := bearerToken(req.Header.Get("Authorization"))
+
+	err := app.models.Tokens.DeleteSessionByHash(req.Context(), data.HashToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidAuthenticationTokenResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "authentication token successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// deleteAllAuthenticationTokensHandler logs the user out everywhere, including
+// the request's own token.
+func (app *application) deleteAllAuthenticationTokensHandler(resp http.ResponseWriter, req *http.Request) {
+	err := app.models.WithTx(req.Context(), func(tx data.Models) error {
+		for _, scope := range []string{data.ScopeAuthentication, data.ScopeRefresh} {
+			err := tx.Tokens.DeleteAllForUser(req.Context(), scope, app.contextGetUser(req).ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "all authentication tokens successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRefreshAuthenticationToken(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	insertTestUser(t, app, "alice@example.com")
+
+	type tokenPair struct {
+		Access struct {
+			Token string `json:"token"`
+		} `json:"authentication_token"`
+		Refresh struct {
+			Token string `json:"token"`
+		} `json:"refresh_token"`
+	}
+
+	login := fmt.Sprintf(`{"email": "alice@example.com", "password": %q}`, testPassword)
+	status, _, body := ts.do(t, http.MethodPost, "/v1/tokens/authentication", "", login)
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d logging in; want %d: %s", status, http.StatusCreated, body)
+	}
+
+	var first tokenPair
+	decodeTestJSON(t, body, &first)
+
+	refresh := func(token string) (int, tokenPair) {
+		t.Helper()
+
+		status, _, body := ts.do(t, http.MethodPost, "/v1/tokens/refresh", "", fmt.Sprintf(`{"token": %q}`, token))
+
+		var pair tokenPair
+		if status == http.StatusCreated {
+			decodeTestJSON(t, body, &pair)
+		}
+		return status, pair
+	}
+
+	checkAccess := func(token string, want int) {
+		t.Helper()
+
+		status, _, body := ts.do(t, http.MethodGet, "/v1/users/me", token, "")
+		if status != want {
+			t.Errorf("got status %d using access token; want %d: %s", status, want, body)
+		}
+	}
+
+	checkAccess(first.Access.Token, http.StatusOK)
+
+	status, second := refresh(first.Refresh.Token)
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d rotating; want %d", status, http.StatusCreated)
+	}
+
+	// Rotation replaces the access token as well as the refresh token.
+	checkAccess(first.Access.Token, http.StatusUnauthorized)
+	checkAccess(second.Access.Token, http.StatusOK)
+
+	// Presenting a spent refresh token ends the whole session.
+	status, _ = refresh(first.Refresh.Token)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d reusing a refresh token; want %d", status, http.StatusUnprocessableEntity)
+	}
+
+	checkAccess(second.Access.Token, http.StatusUnauthorized)
+
+	status, _ = refresh(second.Refresh.Token)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d rotating a revoked session; want %d", status, http.StatusUnprocessableEntity)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"greenlight/internal/data"
 	"greenlight/internal/validator"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -39,7 +40,22 @@ func (app *application) registerUserHandler(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	err = app.models.Users.Insert(user)
+	var token *data.Token
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Users.Insert(req.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Permissions.AddForUser(req.Context(), user.ID, "movies:read")
+		if err != nil {
+			return err
+		}
+
+		token, err = tx.Tokens.New(req.Context(), user.ID, 3*24*time.Hour, data.ScopeActivation)
+		return err
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
@@ -51,18 +67,6 @@ func (app *application) registerUserHandler(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	err = app.models.Permissions.AddForUser(user.ID, "movies:read")
-	if err != nil {
-		app.serverErrorResponse(resp, req, err)
-		return
-	}
-
-	token, err := app.models.Tokens.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
-	if err != nil {
-		app.serverErrorResponse(resp, req, err)
-		return
-	}
-
 	app.background(func() {
 		data := map[string]any{
 			"activationToken": token.Plaintext,
@@ -98,7 +102,7 @@ func (app *application) activateUserHandler(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	user, err := app.models.Users.GetForToken(data.ScopeActivation, input.TokenPlaintext)
+	user, err := app.models.Users.GetForToken(req.Context(), data.ScopeActivation, input.TokenPlaintext)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -113,7 +117,14 @@ func (app *application) activateUserHandler(resp http.ResponseWriter, req *http.
 
 	user.Activated = true
 
-	err = app.models.Users.Update(user)
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Users.Update(req.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		return tx.Tokens.DeleteAllForUser(req.Context(), data.ScopeActivation, user.ID)
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
@@ -125,12 +136,6 @@ func (app *application) activateUserHandler(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	err = app.models.Tokens.DeleteAllForUser(data.ScopeActivation, user.ID)
-	if err != nil {
-		app.serverErrorResponse(resp, req, err)
-		return
-	}
-
 	err = app.writeJSON(resp, http.StatusOK, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
@@ -159,7 +164,7 @@ func (app *application) updateUserPasswordHandler(resp http.ResponseWriter, req
 		return
 	}
 
-	user, err := app.models.Users.GetForToken(data.ScopePasswordReset, input.TokenPlaintext)
+	user, err := app.models.Users.GetForToken(req.Context(), data.ScopePasswordReset, input.TokenPlaintext)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -177,7 +182,23 @@ func (app *application) updateUserPasswordHandler(resp http.ResponseWriter, req
 		return
 	}
 
-	err = app.models.Users.Update(user)
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Users.Update(req.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		// Sessions opened with the old password are ended along with the
+		// reset tokens.
+		for _, scope := range []string{data.ScopePasswordReset, data.ScopeAuthentication, data.ScopeRefresh} {
+			err = tx.Tokens.DeleteAllForUser(req.Context(), scope, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
@@ -188,15 +209,310 @@ func (app *application) updateUserPasswordHandler(resp http.ResponseWriter, req
 		return
 	}
 
-	err = app.models.Tokens.DeleteAllForUser(data.ScopePasswordReset, user.ID)
+	env := envelope{"message": "your password was successfully reset"}
+
+	err = app.writeJSON(resp, http.StatusOK, env, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) showCurrentUserHandler(resp http.ResponseWriter, req *http.Request) {
+	user := app.contextGetUser(req)
+
+	etag := strongETag(user.ID, int64(user.Version))
+	if app.notModified(resp, req, etag) {
 		return
 	}
 
-	env := envelope{"message": "your password was successfully reset"}
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
 
-	err = app.writeJSON(resp, http.StatusOK, env, nil)
+	err := app.writeJSON(resp, http.StatusOK, envelope{"user": user}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// updateCurrentUserHandler changes the name of the authenticated user. An
+// If-Match header with the ETag from GET /v1/users/me guards against lost
+// updates between clients.
+func (app *application) updateCurrentUserHandler(resp http.ResponseWriter, req *http.Request) {
+	user := app.contextGetUser(req)
+
+	ifMatch := req.Header.Get("If-Match")
+	if ifMatch != "" && !etagMatches(ifMatch, strongETag(user.ID, int64(user.Version)), false) {
+		app.preconditionFailedResponse(resp, req)
+		return
+	}
+
+	var input struct {
+		Name *string `json:"name"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+
+	v := validator.New()
+	if data.ValidateUser(v, user); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	err = app.models.Users.Update(req.Context(), user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", strongETag(user.ID, int64(user.Version)))
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"user": user}, headers)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// deleteCurrentUserHandler deletes the account of the authenticated user,
+// who has to confirm it with their password.
+func (app *application) deleteCurrentUserHandler(resp http.ResponseWriter, req *http.Request) {
+	user := app.contextGetUser(req)
+
+	var input struct {
+		Password string `json:"password"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.Password != "", "password", "must be provided"); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	match, err := user.Password.Matches(input.Password)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if !match {
+		app.invalidCredentialsResponse(resp, req)
+		return
+	}
+
+	err = app.models.Users.Delete(req.Context(), user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundErrorRespone(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"message": "your account was successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+func (app *application) listCurrentUserPermissionsHandler(resp http.ResponseWriter, req *http.Request) {
+	permissions, err := app.models.Permissions.GetAllForUser(req.Context(), app.contextGetUser(req).ID)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	if permissions == nil {
+		permissions = data.Permissions{}
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"permissions": permissions}, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// requestEmailChangeHandler records a new email address for the authenticated
+// user and mails a confirmation token to it. The current address stays in use,
+// and is told about the request, until the change is confirmed.
+func (app *application) requestEmailChangeHandler(resp http.ResponseWriter, req *http.Request) {
+	user := app.contextGetUser(req)
+
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateEmail(v, input.Email)
+	v.Check(!strings.EqualFold(input.Email, user.Email), "email", "must be different from your current email address")
+
+	if !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	_, err = app.models.Users.GetByEmail(req.Context(), input.Email)
+	switch {
+	case err == nil:
+		v.AddError("email", "a user with this email address already exists")
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	case !errors.Is(err, data.ErrRecordNotFound):
+		app.serverErrorResponse(resp, req, err)
+		return
+	}
+
+	user.PendingEmail = input.Email
+
+	var token *data.Token
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Users.Update(req.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Tokens.DeleteAllForUser(req.Context(), data.ScopeEmailChange, user.ID)
+		if err != nil {
+			return err
+		}
+
+		token, err = tx.Tokens.New(req.Context(), user.ID, 24*time.Hour, data.ScopeEmailChange)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	app.background(func() {
+		data := map[string]any{
+			"email":            user.PendingEmail,
+			"emailChangeToken": token.Plaintext,
+		}
+
+		err := app.mailer.Send(user.PendingEmail, "token_email_change.tmpl.html", data)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+
+		err = app.mailer.Send(user.Email, "email_change_notice.tmpl.html", data)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	})
+
+	env := envelope{"message": "an email will be sent to the new address containing confirmation instructions"}
+
+	err = app.writeJSON(resp, http.StatusAccepted, env, nil)
+	if err != nil {
+		app.serverErrorResponse(resp, req, err)
+	}
+}
+
+// confirmEmailChangeHandler replaces the email address of the authenticated
+// user with their pending one. Uniqueness is checked again here, as another
+// account may have taken the address since the change was requested.
+func (app *application) confirmEmailChangeHandler(resp http.ResponseWriter, req *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := app.readJSON(resp, req, &input)
+	if err != nil {
+		app.badRequestResponse(resp, req, err)
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateTokenPlaintext(v, input.TokenPlaintext); !v.Valid() {
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	user, err := app.models.Users.GetForToken(req.Context(), data.ScopeEmailChange, input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired email change token")
+			app.failedValidationResponse(resp, req, v.Errors)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	if user.ID != app.contextGetUser(req).ID || user.PendingEmail == "" {
+		v.AddError("token", "invalid or expired email change token")
+		app.failedValidationResponse(resp, req, v.Errors)
+		return
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+
+	err = app.models.WithTx(req.Context(), func(tx data.Models) error {
+		err := tx.Users.Update(req.Context(), user)
+		if err != nil {
+			return err
+		}
+
+		// Password reset tokens were sent to the old address.
+		for _, scope := range []string{data.ScopeEmailChange, data.ScopePasswordReset} {
+			err = tx.Tokens.DeleteAllForUser(req.Context(), scope, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			app.failedValidationResponse(resp, req, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(resp, req)
+		default:
+			app.serverErrorResponse(resp, req, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(resp, http.StatusOK, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(resp, req, err)
 	}
@@ -3,12 +3,16 @@ module greenlight
 go 1.23.1
 
 require (
-	github.com/go-mail/mail v2.3.1+incompatible // indirect
+	github.com/go-mail/mail v2.3.1+incompatible
+	github.com/julienschmidt/httprouter v1.3.0
+	github.com/lib/pq v1.10.9
+	github.com/tomasen/realip v0.0.0-20180522021738-f0c99a92ddce
+	golang.org/x/crypto v0.28.0
+	golang.org/x/image v0.25.0
+	golang.org/x/time v0.7.0
+)
+
+require (
 	github.com/go-mail/mail/v2 v2.3.0 // indirect
-	github.com/julienschmidt/httprouter v1.3.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	github.com/tomasen/realip v0.0.0-20180522021738-f0c99a92ddce // indirect
-	golang.org/x/crypto v0.28.0 // indirect
-	golang.org/x/time v0.7.0 // indirect
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 )
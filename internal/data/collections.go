@@ -0,0 +1,548 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"greenlight/internal/validator"
+)
+
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+var ErrDuplicateCollectionItem = errors.New("duplicate collection item")
+
+// Collection is an ordered list of movies owned by a user. Unlisted and public
+// collections can be viewed by anyone who knows the ShareID; only public ones
+// are listed.
+type Collection struct {
+	ID          int64             `json:"id"`
+	UserID      int64             `json:"user_id"`
+	ShareID     string            `json:"share_id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Visibility  string            `json:"visibility"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ItemCount   int               `json:"item_count"`
+	Version     int32             `json:"version"`
+	Items       []*CollectionItem `json:"items,omitempty"`
+}
+
+// CollectionItem is a movie in a collection. Items of movies in the trash are
+// kept but not returned, so positions can have gaps.
+type CollectionItem struct {
+	CollectionID int64     `json:"-"`
+	MovieID      int64     `json:"movie_id"`
+	Title        string    `json:"title"`
+	Year         int32     `json:"year"`
+	Position     int       `json:"position"`
+	Note         string    `json:"note,omitempty"`
+	AddedAt      time.Time `json:"added_at"`
+}
+
+type CollectionModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func ValidateCollection(v *validator.Validator, collection *Collection) {
+	v.Check(collection.Name != "", "name", "must be provided")
+	v.Check(len(collection.Name) <= 200, "name", "must not be more than 200 bytes long")
+	v.Check(len(collection.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+	v.Check(validator.PermittedValue(collection.Visibility, VisibilityPrivate, VisibilityUnlisted, VisibilityPublic), "visibility", "must be private, unlisted or public")
+}
+
+func ValidateCollectionItem(v *validator.Validator, item *CollectionItem) {
+	v.Check(item.MovieID != 0, "movie_id", "must be provided")
+	v.Check(item.MovieID >= 0, "movie_id", "must be a positive integer")
+	v.Check(item.Position >= 0, "position", "must not be negative")
+	v.Check(len(item.Note) <= 1000, "note", "must not be more than 1000 bytes long")
+}
+
+func generateShareID() (string, error) {
+	randomBytes := make([]byte, 10)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)), nil
+}
+
+// clampPosition limits a requested 1-based position to the range 1..last,
+// treating 0 as the end of the list.
+func clampPosition(position, last int) int {
+	if position < 1 || position > last {
+		return last
+	}
+	return position
+}
+
+func (m CollectionModel) Insert(ctx context.Context, collection *Collection) error {
+	shareID, err := generateShareID()
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO collections (user_id, share_id, name, description, visibility)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, version`
+	args := []any{collection.UserID, shareID, collection.Name, collection.Description, collection.Visibility}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, args...).Scan(&collection.ID, &collection.CreatedAt, &collection.Version)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	collection.ShareID = shareID
+
+	return nil
+}
+
+func (m CollectionModel) Get(ctx context.Context, id int64) (*Collection, error) {
+	query := `
+        SELECT id, user_id, share_id, name, description, visibility, created_at, version,
+        (SELECT COUNT(*) FROM collection_items
+         INNER JOIN movies ON movies.id = collection_items.movie_id
+         WHERE collection_id = collections.id AND movies.deleted_at IS NULL)
+        FROM collections
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var collection Collection
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.ShareID,
+		&collection.Name,
+		&collection.Description,
+		&collection.Visibility,
+		&collection.CreatedAt,
+		&collection.Version,
+		&collection.ItemCount,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &collection, nil
+}
+
+func (m CollectionModel) GetByShareID(ctx context.Context, shareID string) (*Collection, error) {
+	query := `
+        SELECT id, user_id, share_id, name, description, visibility, created_at, version,
+        (SELECT COUNT(*) FROM collection_items
+         INNER JOIN movies ON movies.id = collection_items.movie_id
+         WHERE collection_id = collections.id AND movies.deleted_at IS NULL)
+        FROM collections
+        WHERE share_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var collection Collection
+	err := m.DB.QueryRowContext(ctx, query, shareID).Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.ShareID,
+		&collection.Name,
+		&collection.Description,
+		&collection.Visibility,
+		&collection.CreatedAt,
+		&collection.Version,
+		&collection.ItemCount,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &collection, nil
+}
+
+func (m CollectionModel) GetAllForUser(ctx context.Context, userID int64) ([]*Collection, error) {
+	query := `
+        SELECT id, user_id, share_id, name, description, visibility, created_at, version,
+        (SELECT COUNT(*) FROM collection_items
+         INNER JOIN movies ON movies.id = collection_items.movie_id
+         WHERE collection_id = collections.id AND movies.deleted_at IS NULL)
+        FROM collections
+        WHERE user_id = $1
+        ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	collections := []*Collection{}
+	for rows.Next() {
+		var collection Collection
+		err := rows.Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.ShareID,
+			&collection.Name,
+			&collection.Description,
+			&collection.Visibility,
+			&collection.CreatedAt,
+			&collection.Version,
+			&collection.ItemCount,
+		)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		collections = append(collections, &collection)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return collections, nil
+}
+
+// GetAllPublic returns a page of the collections with public visibility.
+func (m CollectionModel) GetAllPublic(ctx context.Context, filters Filters) ([]*Collection, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT COUNT(*) OVER(), id, user_id, share_id, name, description, visibility, created_at, version,
+        (SELECT COUNT(*) FROM collection_items
+         INNER JOIN movies ON movies.id = collection_items.movie_id
+         WHERE collection_id = collections.id AND movies.deleted_at IS NULL)
+        FROM collections
+        WHERE visibility = 'public'
+        ORDER BY %s %s, id ASC
+        LIMIT $1 OFFSET $2`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	collections := []*Collection{}
+	for rows.Next() {
+		var collection Collection
+		err := rows.Scan(
+			&totalRecords,
+			&collection.ID,
+			&collection.UserID,
+			&collection.ShareID,
+			&collection.Name,
+			&collection.Description,
+			&collection.Visibility,
+			&collection.CreatedAt,
+			&collection.Version,
+			&collection.ItemCount,
+		)
+		if err != nil {
+			return nil, Metadata{}, contextErr(ctx, err)
+		}
+		collections = append(collections, &collection)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return collections, metadata, nil
+}
+
+func (m CollectionModel) Update(ctx context.Context, collection *Collection) error {
+	query := `
+        UPDATE collections
+        SET name = $1, description = $2, visibility = $3, version = version + 1
+        WHERE id = $4 AND version = $5
+        RETURNING version`
+	args := []any{collection.Name, collection.Description, collection.Visibility, collection.ID, collection.Version}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&collection.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m CollectionModel) Delete(ctx context.Context, id int64) error {
+	query := `
+        DELETE FROM collections
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetItems returns the items of a collection in order, leaving out movies in
+// the trash.
+func (m CollectionModel) GetItems(ctx context.Context, collectionID int64) ([]*CollectionItem, error) {
+	query := `
+        SELECT collection_id, movie_id, movies.title, movies.year, position, note, added_at
+        FROM collection_items
+        INNER JOIN movies ON movies.id = collection_items.movie_id
+        WHERE collection_id = $1 AND movies.deleted_at IS NULL
+        ORDER BY position, added_at`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, collectionID)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	items := []*CollectionItem{}
+	for rows.Next() {
+		var item CollectionItem
+		err := rows.Scan(
+			&item.CollectionID,
+			&item.MovieID,
+			&item.Title,
+			&item.Year,
+			&item.Position,
+			&item.Note,
+			&item.AddedAt,
+		)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		items = append(items, &item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return items, nil
+}
+
+func (m CollectionModel) GetItem(ctx context.Context, collectionID, movieID int64) (*CollectionItem, error) {
+	query := `
+        SELECT collection_id, movie_id, movies.title, movies.year, position, note, added_at
+        FROM collection_items
+        INNER JOIN movies ON movies.id = collection_items.movie_id
+        WHERE collection_id = $1 AND movie_id = $2 AND movies.deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var item CollectionItem
+	err := m.DB.QueryRowContext(ctx, query, collectionID, movieID).Scan(
+		&item.CollectionID,
+		&item.MovieID,
+		&item.Title,
+		&item.Year,
+		&item.Position,
+		&item.Note,
+		&item.AddedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &item, nil
+}
+
+// AddItem inserts item at its position, shifting later items down, or appends
+// it when the position is 0 or past the end.
+func (m CollectionModel) AddItem(ctx context.Context, item *CollectionItem) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		count, err := lockCollectionItems(ctx, db, item.CollectionID)
+		if err != nil {
+			return err
+		}
+		item.Position = clampPosition(item.Position, count+1)
+
+		_, err = db.ExecContext(ctx, `
+            UPDATE collection_items
+            SET position = position + 1
+            WHERE collection_id = $1 AND position >= $2`, item.CollectionID, item.Position)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		query := `
+            INSERT INTO collection_items (collection_id, movie_id, position, note)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (collection_id, movie_id) DO NOTHING
+            RETURNING added_at, (SELECT title FROM movies WHERE id = $2), (SELECT year FROM movies WHERE id = $2)`
+		args := []any{item.CollectionID, item.MovieID, item.Position, item.Note}
+
+		err = db.QueryRowContext(ctx, query, args...).Scan(&item.AddedAt, &item.Title, &item.Year)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrDuplicateCollectionItem
+			case err.Error() == `pq: insert or update on table "collection_items" violates foreign key constraint "collection_items_movie_id_fkey"`:
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// UpdateItem saves the note of item and moves it to its position, shifting
+// the items in between.
+func (m CollectionModel) UpdateItem(ctx context.Context, item *CollectionItem) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		count, err := lockCollectionItems(ctx, db, item.CollectionID)
+		if err != nil {
+			return err
+		}
+
+		var current int
+		err = db.QueryRowContext(ctx, `
+            SELECT position FROM collection_items
+            WHERE collection_id = $1 AND movie_id = $2`, item.CollectionID, item.MovieID).Scan(&current)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+		item.Position = clampPosition(item.Position, count)
+
+		query := `
+            UPDATE collection_items
+            SET position = CASE
+                WHEN movie_id = $2 THEN $4
+                WHEN $4 < $3 AND position >= $4 AND position < $3 THEN position + 1
+                WHEN $4 > $3 AND position > $3 AND position <= $4 THEN position - 1
+                ELSE position
+            END,
+            note = CASE WHEN movie_id = $2 THEN $5 ELSE note END
+            WHERE collection_id = $1`
+		args := []any{item.CollectionID, item.MovieID, current, item.Position, item.Note}
+
+		_, err = db.ExecContext(ctx, query, args...)
+		return contextErr(ctx, err)
+	})
+}
+
+// RemoveItem deletes a movie from a collection, closing the gap it leaves.
+func (m CollectionModel) RemoveItem(ctx context.Context, collectionID, movieID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		_, err := lockCollectionItems(ctx, db, collectionID)
+		if err != nil {
+			return err
+		}
+
+		var position int
+		err = db.QueryRowContext(ctx, `
+            DELETE FROM collection_items
+            WHERE collection_id = $1 AND movie_id = $2
+            RETURNING position`, collectionID, movieID).Scan(&position)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		_, err = db.ExecContext(ctx, `
+            UPDATE collection_items
+            SET position = position - 1
+            WHERE collection_id = $1 AND position > $2`, collectionID, position)
+		return contextErr(ctx, err)
+	})
+}
+
+// lockCollectionItems locks the collection row so that concurrent changes to
+// item positions are serialised, and returns the number of items it has.
+func lockCollectionItems(ctx context.Context, db dbtx, collectionID int64) (int, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, "SELECT id FROM collections WHERE id = $1 FOR UPDATE", collectionID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrRecordNotFound
+		default:
+			return 0, contextErr(ctx, err)
+		}
+	}
+
+	var count int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM collection_items WHERE collection_id = $1", collectionID).Scan(&count)
+	if err != nil {
+		return 0, contextErr(ctx, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,201 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"greenlight/internal/validator"
+)
+
+const (
+	CreditDirector = "director"
+	CreditWriter   = "writer"
+	CreditCast     = "cast"
+)
+
+var ErrDuplicateCredit = errors.New("duplicate credit")
+
+// Credit links a person to a movie in a role. Character only applies to cast
+// credits; Billing orders the credits of a role, lowest first.
+type Credit struct {
+	ID        int64  `json:"id"`
+	MovieID   int64  `json:"movie_id"`
+	PersonID  int64  `json:"person_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"`
+	Billing   int32  `json:"billing,omitempty"`
+}
+
+type CreditModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func ValidateCredit(v *validator.Validator, credit *Credit) {
+	v.Check(credit.PersonID != 0, "person_id", "must be provided")
+	v.Check(credit.PersonID >= 0, "person_id", "must be a positive integer")
+
+	v.Check(credit.Role != "", "role", "must be provided")
+	v.Check(validator.PermittedValue(credit.Role, CreditDirector, CreditWriter, CreditCast), "role", "must be director, writer or cast")
+
+	v.Check(len(credit.Character) <= 500, "character", "must not be more than 500 bytes long")
+	v.Check(credit.Character == "" || credit.Role == CreditCast, "character", "must only be set for cast credits")
+
+	v.Check(credit.Billing >= 0, "billing", "must not be negative")
+	v.Check(credit.Billing <= 10_000, "billing", "must be a maximum of 10000")
+}
+
+func (m CreditModel) Insert(ctx context.Context, credit *Credit) error {
+	query := `
+        INSERT INTO movie_credits (movie_id, person_id, role, character, billing)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, (SELECT name FROM people WHERE id = $2)`
+	args := []any{credit.MovieID, credit.PersonID, credit.Role, credit.Character, credit.Billing}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&credit.ID, &credit.Name)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "movie_credits_movie_id_person_id_role_character_key"`:
+			return ErrDuplicateCredit
+		case err.Error() == `pq: insert or update on table "movie_credits" violates foreign key constraint "movie_credits_person_id_fkey"`:
+			return ErrRecordNotFound
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m CreditModel) Get(ctx context.Context, movieID, id int64) (*Credit, error) {
+	query := `
+        SELECT movie_credits.id, movie_id, person_id, people.name, role, character, billing
+        FROM movie_credits
+        INNER JOIN people ON people.id = movie_credits.person_id
+        WHERE movie_credits.id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var credit Credit
+	err := m.DB.QueryRowContext(ctx, query, id, movieID).Scan(
+		&credit.ID,
+		&credit.MovieID,
+		&credit.PersonID,
+		&credit.Name,
+		&credit.Role,
+		&credit.Character,
+		&credit.Billing,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &credit, nil
+}
+
+// GetAllForMovie returns the credits of a movie, directors first, then
+// writers and cast, each in billing order.
+func (m CreditModel) GetAllForMovie(ctx context.Context, movieID int64) ([]*Credit, error) {
+	query := `
+        SELECT movie_credits.id, movie_id, person_id, people.name, role, character, billing
+        FROM movie_credits
+        INNER JOIN people ON people.id = movie_credits.person_id
+        WHERE movie_id = $1
+        ORDER BY array_position(ARRAY['director', 'writer', 'cast'], role), billing, movie_credits.id`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	credits := []*Credit{}
+	for rows.Next() {
+		var credit Credit
+		err := rows.Scan(
+			&credit.ID,
+			&credit.MovieID,
+			&credit.PersonID,
+			&credit.Name,
+			&credit.Role,
+			&credit.Character,
+			&credit.Billing,
+		)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		credits = append(credits, &credit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return credits, nil
+}
+
+func (m CreditModel) Update(ctx context.Context, credit *Credit) error {
+	query := `
+        UPDATE movie_credits
+        SET person_id = $1, role = $2, character = $3, billing = $4
+        WHERE id = $5 AND movie_id = $6
+        RETURNING (SELECT name FROM people WHERE id = $1)`
+	args := []any{credit.PersonID, credit.Role, credit.Character, credit.Billing, credit.ID, credit.MovieID}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&credit.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		case err.Error() == `pq: duplicate key value violates unique constraint "movie_credits_movie_id_person_id_role_character_key"`:
+			return ErrDuplicateCredit
+		case err.Error() == `pq: insert or update on table "movie_credits" violates foreign key constraint "movie_credits_person_id_fkey"`:
+			return ErrRecordNotFound
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m CreditModel) Delete(ctx context.Context, movieID, id int64) error {
+	query := `
+        DELETE FROM movie_credits
+        WHERE id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, movieID)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// runtimeBucketBounds are the lower bounds of the runtime facet buckets after
+// the first, which covers everything shorter than 90 minutes.
+var runtimeBucketBounds = []Runtime{90, 120, 150, 180}
+
+type MovieFacets struct {
+	Total    int            `json:"total"`
+	Genres   []GenreFacet   `json:"genres"`
+	Years    []YearFacet    `json:"years"`
+	Runtimes []RuntimeFacet `json:"runtimes"`
+}
+
+type GenreFacet struct {
+	Genre string `json:"genre"`
+	Count int    `json:"count"`
+}
+
+// YearFacet counts movies released from Year up to the next interval.
+type YearFacet struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+// RuntimeFacet counts movies with From <= runtime < To. To is omitted for the
+// last, open-ended bucket.
+type RuntimeFacet struct {
+	From  Runtime `json:"from"`
+	To    Runtime `json:"to,omitempty"`
+	Count int     `json:"count"`
+}
+
+// Facets counts the movies matching filter per genre, per release year
+// interval (1 for years, 10 for decades) and per runtime bucket. All counts
+// come from a single statement so they agree with each other.
+func (m MovieModel) Facets(ctx context.Context, filter MovieFilter, yearInterval int) (*MovieFacets, error) {
+	q := filter.query()
+	args := append(q.args, yearInterval, pq.Array(runtimeBucketBounds))
+
+	query := fmt.Sprintf(`
+        WITH filtered AS (
+            SELECT genres, year, runtime
+            FROM movies
+            WHERE %[1]s
+        )
+        SELECT 'total', '', COUNT(*) FROM filtered
+        UNION ALL
+        SELECT 'genre', genre, COUNT(*) FROM filtered, unnest(genres) AS genre GROUP BY genre
+        UNION ALL
+        SELECT 'year', (year / $%[2]d * $%[2]d)::text, COUNT(*) FROM filtered GROUP BY 2
+        UNION ALL
+        SELECT 'runtime', width_bucket(runtime, $%[3]d::integer[])::text, COUNT(*) FROM filtered GROUP BY 2`,
+		q.where, len(args)-1, len(args))
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	counts := facetCounts{genres: make(map[string]int), years: make(map[int]int), runtimes: make(map[int]int)}
+	for rows.Next() {
+		var (
+			facet, value string
+			count        int
+		)
+		err := rows.Scan(&facet, &value, &count)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+
+		switch facet {
+		case "total":
+			counts.total = count
+		case "genre":
+			counts.genres[value] = count
+		case "year":
+			year, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			counts.years[year] = count
+		case "runtime":
+			bucket, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			counts.runtimes[bucket] = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return counts.facets(), nil
+}
+
+// facetCounts collects raw counts, with runtime buckets numbered as by
+// PostgreSQL's width_bucket over runtimeBucketBounds.
+type facetCounts struct {
+	total    int
+	genres   map[string]int
+	years    map[int]int
+	runtimes map[int]int
+}
+
+func (c facetCounts) facets() *MovieFacets {
+	facets := &MovieFacets{
+		Total:    c.total,
+		Genres:   []GenreFacet{},
+		Years:    []YearFacet{},
+		Runtimes: []RuntimeFacet{},
+	}
+
+	for genre, count := range c.genres {
+		facets.Genres = append(facets.Genres, GenreFacet{Genre: genre, Count: count})
+	}
+	slices.SortFunc(facets.Genres, func(a, b GenreFacet) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Genre, b.Genre)
+	})
+
+	for year, count := range c.years {
+		facets.Years = append(facets.Years, YearFacet{Year: year, Count: count})
+	}
+	slices.SortFunc(facets.Years, func(a, b YearFacet) int {
+		return cmp.Compare(a.Year, b.Year)
+	})
+
+	for bucket := 0; bucket <= len(runtimeBucketBounds); bucket++ {
+		facet := RuntimeFacet{Count: c.runtimes[bucket]}
+		if bucket > 0 {
+			facet.From = runtimeBucketBounds[bucket-1]
+		}
+		if bucket < len(runtimeBucketBounds) {
+			facet.To = runtimeBucketBounds[bucket]
+		}
+		facets.Runtimes = append(facets.Runtimes, facet)
+	}
+
+	return facets
+}
+
+func runtimeBucket(runtime Runtime) int {
+	bucket := 0
+	for _, bound := range runtimeBucketBounds {
+		if runtime >= bound {
+			bucket++
+		}
+	}
+	return bucket
+}
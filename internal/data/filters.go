@@ -1,23 +1,51 @@
 package data
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"greenlight/internal/validator"
 	"strings"
 )
 
+var errInvalidCursor = errors.New("invalid cursor")
+
+// sortColumns maps sort values to the column they order by, where the two
+// differ.
+var sortColumns = map[string]string{
+	"rating": "average_rating",
+}
+
 type Filters struct {
 	Page         int
 	PageSize     int
 	Sort         string
 	SortSafeList []string
+	Cursor       string
+	CursorSecret []byte
+	IncludeTotal bool
 }
 
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
+}
+
+// cursor identifies a position in a keyset-paginated listing: the value of the
+// sort column and the id tie-breaker of the row to continue from.
+type cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       int64  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
 }
 
 func ValidateFilters(v *validator.Validator, f Filters) {
@@ -26,12 +54,24 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.Check(f.PageSize < 100, "page_size", "must be a maximum of 100")
 	v.Check(validator.PermittedValue(f.Sort, f.SortSafeList...), "sort", "invalid sort value")
+
+	if f.Cursor != "" {
+		v.Check(f.Page == 1, "page", "must not be combined with cursor")
+
+		c, err := decodeCursor(f.CursorSecret, f.Cursor)
+		v.Check(err == nil, "cursor", "invalid cursor")
+		v.Check(err != nil || c.Sort == f.Sort, "cursor", "must be used with the sort it was issued for")
+	}
 }
 
 func (f Filters) sortColumn() string {
 	for _, safeValue := range f.SortSafeList {
 		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+			column := strings.TrimPrefix(f.Sort, "-")
+			if mapped, ok := sortColumns[column]; ok {
+				return mapped
+			}
+			return column
 		}
 	}
 	panic("unsafe sort parameter: " + f.Sort)
@@ -52,6 +92,187 @@ func (f Filters) offset() int {
 	return (f.Page - 1) * f.PageSize
 }
 
+// keyset reports whether the sort order can be paginated with cursors.
+// Relevance depends on the search terms rather than a stored column, so it
+// only supports page numbers.
+func (f Filters) keyset() bool {
+	return f.sortColumn() != "relevance"
+}
+
+func (f Filters) cursor() (*cursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+
+	c, err := decodeCursor(f.CursorSecret, f.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// orderBy returns the ORDER BY clause for the listing, reversed when reading
+// backwards from a cursor so that LIMIT picks the rows closest to it.
+func (f Filters) orderBy(c *cursor) string {
+	if !f.keyset() {
+		return "relevance DESC, id ASC"
+	}
+
+	direction, tieBreaker := f.sortDirection(), "ASC"
+	if c != nil && c.Backward {
+		direction, tieBreaker = reverseDirection(direction), "DESC"
+	}
+
+	return fmt.Sprintf("%s %s, id %s", f.sortColumn(), direction, tieBreaker)
+}
+
+// keysetCondition returns the predicate selecting rows strictly after (or
+// before, for a backward cursor) the cursor position, using the given
+// placeholders for the sort value and id.
+func (f Filters) keysetCondition(c *cursor, valueParam, idParam int) string {
+	column, comparison, tieBreaker := f.sortColumn(), ">", ">"
+	if f.sortDirection() == "DESC" {
+		comparison = "<"
+	}
+	if c.Backward {
+		comparison, tieBreaker = reverseComparison(comparison), "<"
+	}
+
+	return fmt.Sprintf("(%[1]s %[2]s $%[4]d OR (%[1]s = $%[4]d AND id %[3]s $%[5]d))",
+		column, comparison, tieBreaker, valueParam, idParam)
+}
+
+// conditions accumulates SQL predicates whose values are passed as query
+// arguments. Each clause is a format string with one %d verb per argument,
+// which is replaced by the argument's placeholder number.
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+func (c *conditions) add(clause string, args ...any) {
+	placeholders := make([]any, len(args))
+	for i, arg := range args {
+		placeholders[i] = c.param(arg)
+	}
+
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, placeholders...))
+}
+
+// param records arg and returns its placeholder number, for expressions that
+// refer to the same argument more than once.
+func (c *conditions) param(arg any) int {
+	c.args = append(c.args, arg)
+	return len(c.args)
+}
+
+func (c *conditions) join(or bool) string {
+	separator := " AND "
+	if or {
+		separator = " OR "
+	}
+
+	return "(" + strings.Join(c.clauses, separator) + ")"
+}
+
+func reverseDirection(direction string) string {
+	if direction == "DESC" {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+func reverseComparison(comparison string) string {
+	if comparison == "<" {
+		return ">"
+	}
+	return "<"
+}
+
+func encodeCursor(secret []byte, c cursor) string {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func decodeCursor(secret []byte, s string) (cursor, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(s, ".")
+	if !found {
+		return cursor{}, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return cursor{}, errInvalidCursor
+	}
+
+	var c cursor
+	err = json.Unmarshal(payload, &c)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// pageMetadata builds the response metadata once rows have been fetched with
+// a limit one larger than the page size; hasMore reports whether that extra
+// row was present. first and last are the cursor positions of the returned
+// rows and are ignored when the page is empty.
+func (f Filters) pageMetadata(c *cursor, totalRecords, count int, hasMore bool, first, last cursor) Metadata {
+	var metadata Metadata
+
+	switch {
+	case c == nil && f.IncludeTotal:
+		metadata = calculateMetadata(totalRecords, f.Page, f.PageSize)
+	case c == nil && count > 0:
+		metadata = Metadata{CurrentPage: f.Page, PageSize: f.PageSize, FirstPage: 1}
+	case c != nil:
+		metadata = Metadata{PageSize: f.PageSize}
+		if f.IncludeTotal {
+			metadata.TotalRecords = totalRecords
+		}
+	}
+
+	if count == 0 || !f.keyset() {
+		return metadata
+	}
+
+	first.Sort, first.Backward = f.Sort, true
+	last.Sort, last.Backward = f.Sort, false
+
+	backward := c != nil && c.Backward
+	hasNext := hasMore || backward
+	hasPrev := (hasMore && backward) || (!backward && (c != nil || f.Page > 1))
+
+	if hasNext {
+		metadata.NextCursor = encodeCursor(f.CursorSecret, last)
+	}
+	if hasPrev {
+		metadata.PrevCursor = encodeCursor(f.CursorSecret, first)
+	}
+
+	return metadata
+}
+
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
 	if totalRecords == 0 {
 		return Metadata{}
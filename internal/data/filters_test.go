@@ -0,0 +1,63 @@
+package data
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	secret := []byte("secret")
+
+	tests := []struct {
+		name   string
+		cursor cursor
+	}{
+		{"forward", cursor{Sort: "title", Value: "Moana", ID: 42}},
+		{"backward", cursor{Sort: "-year", Value: "2016", ID: 7, Backward: true}},
+		{"empty value", cursor{Sort: "id", ID: 1}},
+		{"unicode value", cursor{Sort: "title", Value: "Amélie", ID: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(secret, encodeCursor(secret, tt.cursor))
+			if err != nil {
+				t.Fatalf("decodeCursor: unexpected error: %v", err)
+			}
+			if got != tt.cursor {
+				t.Errorf("got %+v; want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	secret := []byte("secret")
+	valid := encodeCursor(secret, cursor{Sort: "title", Value: "Moana", ID: 42})
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"title","v":"Moana","i":1}`))
+
+	tests := []struct {
+		name   string
+		secret []byte
+		cursor string
+	}{
+		{"empty", secret, ""},
+		{"missing signature", secret, payload},
+		{"forged payload", secret, forged + "." + signature},
+		{"truncated signature", secret, payload + "." + signature[:len(signature)-2]},
+		{"invalid base64", secret, payload + ".!!!"},
+		{"other secret", []byte("other"), valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.secret, tt.cursor)
+			if err != errInvalidCursor {
+				t.Errorf("got error %v; want %v", err, errInvalidCursor)
+			}
+		})
+	}
+}
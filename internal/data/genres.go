@@ -0,0 +1,291 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"greenlight/internal/validator"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateGenre = errors.New("duplicate genre")
+	ErrGenreInUse     = errors.New("genre in use")
+	ErrUnknownGenre   = errors.New("unknown genre")
+)
+
+// Genre is an entry in the genre vocabulary. Movies store the slug; the
+// aliases are alternative spellings that are rewritten to it on input.
+type Genre struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"-"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases"`
+	Version   int32     `json:"version"`
+}
+
+// GenreVocabulary maps the key of every genre slug and alias to its slug.
+type GenreVocabulary map[string]string
+
+type GenreModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+// GenreKey reduces a genre to lower case words joined by hyphens, so that
+// "Sci Fi", "sci-fi" and "SCI_FI" all compare equal.
+func GenreKey(genre string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(genre), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+}
+
+func NewGenreVocabulary(genres []*Genre) GenreVocabulary {
+	vocabulary := make(GenreVocabulary)
+	for _, genre := range genres {
+		vocabulary[genre.Slug] = genre.Slug
+		for _, alias := range genre.Aliases {
+			vocabulary[alias] = genre.Slug
+		}
+	}
+	return vocabulary
+}
+
+// Normalize returns the slug of the genre that genre names or is an alias of.
+func (gv GenreVocabulary) Normalize(genre string) (string, bool) {
+	slug, ok := gv[GenreKey(genre)]
+	return slug, ok
+}
+
+// ValidateGenre checks genre and rewrites its aliases to their keys, dropping
+// any that only differ from the slug or each other in spelling.
+func ValidateGenre(v *validator.Validator, genre *Genre) {
+	v.Check(genre.Slug != "", "slug", "must be provided")
+	v.Check(len(genre.Slug) <= 50, "slug", "must not be more than 50 bytes long")
+	v.Check(genre.Slug == GenreKey(genre.Slug), "slug", "must only contain lower case letters, digits and single hyphens")
+
+	v.Check(genre.Name != "", "name", "must be provided")
+	v.Check(len(genre.Name) <= 100, "name", "must not be more than 100 bytes long")
+
+	v.Check(len(genre.Aliases) <= 20, "aliases", "must not contain more than 20 aliases")
+
+	aliases := []string{}
+	for _, alias := range genre.Aliases {
+		key := GenreKey(alias)
+		v.Check(key != "", "aliases", "must not contain blank values")
+		v.Check(len(key) <= 50, "aliases", "must not contain values more than 50 bytes long")
+		if key != "" && key != genre.Slug && !slices.Contains(aliases, key) {
+			aliases = append(aliases, key)
+		}
+	}
+	genre.Aliases = aliases
+}
+
+func (m GenreModel) Insert(ctx context.Context, genre *Genre) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		query := `
+            INSERT INTO genres (slug, name, aliases)
+            VALUES ($1, $2, $3)
+            RETURNING id, created_at, version`
+
+		err := db.QueryRowContext(ctx, query, genre.Slug, genre.Name, pq.Array(genre.Aliases)).Scan(&genre.ID, &genre.CreatedAt, &genre.Version)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "genres_slug_key"`:
+				return ErrDuplicateGenre
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		return insertGenreKeys(ctx, db, genre)
+	})
+}
+
+func (m GenreModel) Get(ctx context.Context, slug string) (*Genre, error) {
+	query := `
+        SELECT id, created_at, slug, name, aliases, version
+        FROM genres
+        WHERE slug = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var genre Genre
+	err := m.DB.QueryRowContext(ctx, query, slug).Scan(
+		&genre.ID,
+		&genre.CreatedAt,
+		&genre.Slug,
+		&genre.Name,
+		pq.Array(&genre.Aliases),
+		&genre.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &genre, nil
+}
+
+func (m GenreModel) GetAll(ctx context.Context) ([]*Genre, error) {
+	query := `
+        SELECT id, created_at, slug, name, aliases, version
+        FROM genres
+        ORDER BY slug`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	genres := []*Genre{}
+	for rows.Next() {
+		var genre Genre
+		err := rows.Scan(
+			&genre.ID,
+			&genre.CreatedAt,
+			&genre.Slug,
+			&genre.Name,
+			pq.Array(&genre.Aliases),
+			&genre.Version,
+		)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		genres = append(genres, &genre)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return genres, nil
+}
+
+// Update saves the name and aliases of genre. The slug is immutable because
+// movies and their revisions refer to it.
+func (m GenreModel) Update(ctx context.Context, genre *Genre) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		query := `
+            UPDATE genres
+            SET name = $1, aliases = $2, version = version + 1
+            WHERE slug = $3 AND version = $4
+            RETURNING id, version`
+		args := []any{genre.Name, pq.Array(genre.Aliases), genre.Slug, genre.Version}
+
+		err := db.QueryRowContext(ctx, query, args...).Scan(&genre.ID, &genre.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM genre_keys WHERE genre_id = $1", genre.ID)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		return insertGenreKeys(ctx, db, genre)
+	})
+}
+
+// Delete removes the genre with the given slug, unless a movie, including one
+// in the trash, still uses it. The genre row stays locked until the delete
+// commits, so a concurrent movie write holding it through lockGenres either
+// finishes first and is seen here, or waits and then finds the genre gone.
+func (m GenreModel) Delete(ctx context.Context, slug string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		var id int64
+		err := db.QueryRowContext(ctx, "SELECT id FROM genres WHERE slug = $1 FOR UPDATE", slug).Scan(&id)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		var inUse bool
+		err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE genres @> ARRAY[$1])", slug).Scan(&inUse)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		if inUse {
+			return ErrGenreInUse
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM genres WHERE id = $1", id)
+		return contextErr(ctx, err)
+	})
+}
+
+// lockGenres takes a key share lock on the genres with the given slugs, which
+// holds off GenreModel.Delete until the surrounding movie write commits. It
+// returns ErrUnknownGenre if any of them no longer exists.
+func lockGenres(ctx context.Context, db dbtx, slugs []string) error {
+	query := `
+        SELECT count(*) FROM (
+            SELECT 1 FROM genres WHERE slug = ANY($1) FOR KEY SHARE
+        ) AS locked`
+
+	var count int
+	err := db.QueryRowContext(ctx, query, pq.Array(slugs)).Scan(&count)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	if count != len(slugs) {
+		return ErrUnknownGenre
+	}
+
+	return nil
+}
+
+// insertGenreKeys claims the slug and aliases of genre in genre_keys, whose
+// primary key rejects any already claimed by another genre.
+func insertGenreKeys(ctx context.Context, db dbtx, genre *Genre) error {
+	query := `
+        INSERT INTO genre_keys (key, genre_id)
+        SELECT unnest($1::text[]), $2`
+	keys := append([]string{genre.Slug}, genre.Aliases...)
+
+	_, err := db.ExecContext(ctx, query, pq.Array(keys), genre.ID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "genre_keys_pkey"`:
+			return ErrDuplicateGenre
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package data
+
+import "testing"
+
+func TestGenreVocabularyNormalize(t *testing.T) {
+	vocabulary := NewGenreVocabulary([]*Genre{
+		{Slug: "sci-fi", Aliases: []string{"science-fiction", "scifi"}},
+		{Slug: "drama", Aliases: []string{"dramas"}},
+	})
+
+	tests := []struct {
+		genre  string
+		want   string
+		wantOK bool
+	}{
+		{genre: "sci-fi", want: "sci-fi", wantOK: true},
+		{genre: "Sci Fi", want: "sci-fi", wantOK: true},
+		{genre: "SCI_FI", want: "sci-fi", wantOK: true},
+		{genre: "Science Fiction", want: "sci-fi", wantOK: true},
+		{genre: "scifi", want: "sci-fi", wantOK: true},
+		{genre: "  Dramas ", want: "drama", wantOK: true},
+		{genre: "western"},
+		{genre: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.genre, func(t *testing.T) {
+			got, ok := vocabulary.Normalize(tt.genre)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %q, %t; want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
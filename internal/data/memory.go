@@ -0,0 +1,207 @@
+package data
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// memoryState holds the tables of the in-memory backend. Stored records are
+// never mutated in place, only replaced, so a shallow copy of the maps is a
+// consistent snapshot that a failed transaction can be rolled back to.
+type memoryState struct {
+	movies          map[int64]*Movie
+	revisions       map[int64][]*MovieRevision
+	users           map[int64]*User
+	tokens          map[string]*Token
+	permissions     []string
+	userPerms       map[int64][]string
+	genres          map[string]*Genre
+	people          map[int64]*Person
+	credits         map[int64]*Credit
+	ratings         map[ratingKey]*Rating
+	reviews         map[int64]*Review
+	collections     map[int64]*Collection
+	collectionItems map[collectionItemKey]*CollectionItem
+
+	lastMovieID      int64
+	lastUserID       int64
+	lastGenreID      int64
+	lastPersonID     int64
+	lastCreditID     int64
+	lastReviewID     int64
+	lastCollectionID int64
+	lastTokenID      int64
+}
+
+type memoryDB struct {
+	mu   *sync.RWMutex
+	inTx bool
+	*memoryState
+}
+
+func NewMemoryModels() Models {
+	db := &memoryDB{
+		mu: new(sync.RWMutex),
+		memoryState: &memoryState{
+			movies:          make(map[int64]*Movie),
+			revisions:       make(map[int64][]*MovieRevision),
+			users:           make(map[int64]*User),
+			tokens:          make(map[string]*Token),
+			permissions:     []string{"movies:read", "movies:write", "movies:purge", "movies:export", "genres:write", "reviews:moderate"},
+			userPerms:       make(map[int64][]string),
+			genres:          make(map[string]*Genre),
+			people:          make(map[int64]*Person),
+			credits:         make(map[int64]*Credit),
+			ratings:         make(map[ratingKey]*Rating),
+			reviews:         make(map[int64]*Review),
+			collections:     make(map[int64]*Collection),
+			collectionItems: make(map[collectionItemKey]*CollectionItem),
+		},
+	}
+
+	for _, genre := range defaultGenres {
+		db.lastGenreID++
+		genre := copyGenre(genre)
+		genre.ID = db.lastGenreID
+		genre.CreatedAt = memoryNow()
+		genre.Version = 1
+		db.genres[genre.Slug] = genre
+	}
+
+	return newMemoryModels(db)
+}
+
+func newMemoryModels(db *memoryDB) Models {
+	return Models{
+		Movies:      memoryMovieModel{db: db},
+		Revisions:   memoryMovieRevisionModel{db: db},
+		Users:       memoryUserModel{db: db},
+		Tokens:      memoryTokenModel{db: db},
+		Permissions: memoryPermissionModel{db: db},
+		Genres:      memoryGenreModel{db: db},
+		People:      memoryPersonModel{db: db},
+		Credits:     memoryCreditModel{db: db},
+		Ratings:     memoryRatingModel{db: db},
+		Reviews:     memoryReviewModel{db: db},
+		Collections: memoryCollectionModel{db: db},
+		withTx:      db.withTx,
+	}
+}
+
+func (db *memoryDB) withTx(ctx context.Context, fn func(tx Models) error) error {
+	if db.inTx {
+		return fn(newMemoryModels(db))
+	}
+
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	snapshot := db.memoryState.clone()
+
+	err := fn(newMemoryModels(&memoryDB{mu: db.mu, inTx: true, memoryState: db.memoryState}))
+	if err != nil {
+		*db.memoryState = *snapshot
+		return err
+	}
+
+	return nil
+}
+
+func (db *memoryDB) lock() {
+	if !db.inTx {
+		db.mu.Lock()
+	}
+}
+
+func (db *memoryDB) unlock() {
+	if !db.inTx {
+		db.mu.Unlock()
+	}
+}
+
+func (db *memoryDB) rlock() {
+	if !db.inTx {
+		db.mu.RLock()
+	}
+}
+
+func (db *memoryDB) runlock() {
+	if !db.inTx {
+		db.mu.RUnlock()
+	}
+}
+
+func (s *memoryState) clone() *memoryState {
+	clone := *s
+	clone.movies = maps.Clone(s.movies)
+	clone.revisions = maps.Clone(s.revisions)
+	clone.users = maps.Clone(s.users)
+	clone.tokens = maps.Clone(s.tokens)
+	clone.permissions = slices.Clone(s.permissions)
+	clone.userPerms = maps.Clone(s.userPerms)
+	clone.genres = maps.Clone(s.genres)
+	clone.people = maps.Clone(s.people)
+	clone.credits = maps.Clone(s.credits)
+	clone.ratings = maps.Clone(s.ratings)
+	clone.reviews = maps.Clone(s.reviews)
+	clone.collections = maps.Clone(s.collections)
+	clone.collectionItems = maps.Clone(s.collectionItems)
+	return &clone
+}
+
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+func copyMovie(movie *Movie) *Movie {
+	clone := *movie
+	clone.Genres = slices.Clone(movie.Genres)
+	if movie.DeletedAt != nil {
+		deletedAt := *movie.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+func (db *memoryDB) deleteMovie(id int64) {
+	delete(db.movies, id)
+	delete(db.revisions, id)
+	maps.DeleteFunc(db.credits, func(_ int64, credit *Credit) bool {
+		return credit.MovieID == id
+	})
+	maps.DeleteFunc(db.ratings, func(key ratingKey, _ *Rating) bool {
+		return key.movieID == id
+	})
+	maps.DeleteFunc(db.reviews, func(_ int64, review *Review) bool {
+		return review.MovieID == id
+	})
+	maps.DeleteFunc(db.collectionItems, func(key collectionItemKey, _ *CollectionItem) bool {
+		return key.movieID == id
+	})
+}
+
+func copyUser(user *User) *User {
+	clone := *user
+	clone.Password.plaintext = nil
+	clone.Password.hash = slices.Clone(user.Password.hash)
+	return &clone
+}
+
+func searchTerms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func checkContext(ctx context.Context) error {
+	return contextErr(ctx, ctx.Err())
+}
@@ -0,0 +1,377 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type collectionItemKey struct {
+	collectionID int64
+	movieID      int64
+}
+
+type memoryCollectionModel struct {
+	db *memoryDB
+}
+
+func (m memoryCollectionModel) Insert(ctx context.Context, collection *Collection) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	shareID, err := generateShareID()
+	if err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	m.db.lastCollectionID++
+	collection.ID = m.db.lastCollectionID
+	collection.ShareID = shareID
+	collection.CreatedAt = memoryNow()
+	collection.Version = 1
+
+	clone := *collection
+	clone.Items = nil
+	m.db.collections[collection.ID] = &clone
+
+	return nil
+}
+
+func (m memoryCollectionModel) Get(ctx context.Context, id int64) (*Collection, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	collection, ok := m.db.collections[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	return m.db.copyCollection(collection), nil
+}
+
+func (m memoryCollectionModel) GetByShareID(ctx context.Context, shareID string) (*Collection, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	for _, collection := range m.db.collections {
+		if collection.ShareID == shareID {
+			return m.db.copyCollection(collection), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+func (m memoryCollectionModel) GetAllForUser(ctx context.Context, userID int64) ([]*Collection, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	collections := []*Collection{}
+	for _, collection := range m.db.collections {
+		if collection.UserID == userID {
+			collections = append(collections, m.db.copyCollection(collection))
+		}
+	}
+
+	slices.SortFunc(collections, func(a, b *Collection) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return collections, nil
+}
+
+func (m memoryCollectionModel) GetAllPublic(ctx context.Context, filters Filters) ([]*Collection, Metadata, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	matches := []*Collection{}
+	for _, collection := range m.db.collections {
+		if collection.Visibility == VisibilityPublic {
+			matches = append(matches, collection)
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b *Collection) int {
+		var result int
+		switch filters.sortColumn() {
+		case "name":
+			result = strings.Compare(a.Name, b.Name)
+		default:
+			result = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if filters.sortDirection() == "DESC" {
+			result = -result
+		}
+		return cmp.Or(result, cmp.Compare(a.ID, b.ID))
+	})
+
+	start := min(filters.offset(), len(matches))
+	end := min(start+filters.limit(), len(matches))
+
+	collections := make([]*Collection, 0, end-start)
+	for _, collection := range matches[start:end] {
+		collections = append(collections, m.db.copyCollection(collection))
+	}
+
+	metadata := calculateMetadata(len(matches), filters.Page, filters.PageSize)
+	if len(collections) == 0 {
+		metadata = Metadata{}
+	}
+
+	return collections, metadata, nil
+}
+
+func (m memoryCollectionModel) Update(ctx context.Context, collection *Collection) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.collections[collection.ID]
+	if !ok || stored.Version != collection.Version {
+		return ErrEditConflict
+	}
+
+	updated := *stored
+	updated.Name = collection.Name
+	updated.Description = collection.Description
+	updated.Visibility = collection.Visibility
+	updated.Version++
+	m.db.collections[collection.ID] = &updated
+
+	collection.Version = updated.Version
+
+	return nil
+}
+
+func (m memoryCollectionModel) Delete(ctx context.Context, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.collections[id]; !ok {
+		return ErrRecordNotFound
+	}
+
+	m.db.deleteCollection(id)
+
+	return nil
+}
+
+func (m memoryCollectionModel) GetItems(ctx context.Context, collectionID int64) ([]*CollectionItem, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	items := []*CollectionItem{}
+	for key, item := range m.db.collectionItems {
+		if key.collectionID != collectionID {
+			continue
+		}
+		if clone, ok := m.db.copyCollectionItem(item); ok {
+			items = append(items, clone)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b *CollectionItem) int {
+		return cmp.Or(cmp.Compare(a.Position, b.Position), a.AddedAt.Compare(b.AddedAt))
+	})
+
+	return items, nil
+}
+
+func (m memoryCollectionModel) GetItem(ctx context.Context, collectionID, movieID int64) (*CollectionItem, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	item, ok := m.db.collectionItems[collectionItemKey{collectionID, movieID}]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	clone, ok := m.db.copyCollectionItem(item)
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	return clone, nil
+}
+
+func (m memoryCollectionModel) AddItem(ctx context.Context, item *CollectionItem) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.collections[item.CollectionID]; !ok {
+		return ErrRecordNotFound
+	}
+
+	movie, ok := m.db.movies[item.MovieID]
+	if !ok {
+		return ErrRecordNotFound
+	}
+
+	key := collectionItemKey{item.CollectionID, item.MovieID}
+	if _, ok := m.db.collectionItems[key]; ok {
+		return ErrDuplicateCollectionItem
+	}
+
+	item.Position = clampPosition(item.Position, m.db.countCollectionItems(item.CollectionID)+1)
+	m.db.shiftCollectionItems(item.CollectionID, item.Position, 0, 1)
+
+	item.Title = movie.Title
+	item.Year = movie.Year
+	item.AddedAt = memoryNow()
+
+	clone := *item
+	m.db.collectionItems[key] = &clone
+
+	return nil
+}
+
+func (m memoryCollectionModel) UpdateItem(ctx context.Context, item *CollectionItem) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	key := collectionItemKey{item.CollectionID, item.MovieID}
+	stored, ok := m.db.collectionItems[key]
+	if !ok {
+		return ErrRecordNotFound
+	}
+
+	item.Position = clampPosition(item.Position, m.db.countCollectionItems(item.CollectionID))
+	switch {
+	case item.Position < stored.Position:
+		m.db.shiftCollectionItems(item.CollectionID, item.Position, stored.Position-1, 1)
+	case item.Position > stored.Position:
+		m.db.shiftCollectionItems(item.CollectionID, stored.Position+1, item.Position, -1)
+	}
+
+	updated := *stored
+	updated.Position = item.Position
+	updated.Note = item.Note
+	m.db.collectionItems[key] = &updated
+
+	return nil
+}
+
+func (m memoryCollectionModel) RemoveItem(ctx context.Context, collectionID, movieID int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	key := collectionItemKey{collectionID, movieID}
+	item, ok := m.db.collectionItems[key]
+	if !ok {
+		return ErrRecordNotFound
+	}
+
+	delete(m.db.collectionItems, key)
+	m.db.shiftCollectionItems(collectionID, item.Position+1, 0, -1)
+
+	return nil
+}
+
+func (db *memoryDB) countCollectionItems(collectionID int64) int {
+	count := 0
+	for key := range db.collectionItems {
+		if key.collectionID == collectionID {
+			count++
+		}
+	}
+	return count
+}
+
+// shiftCollectionItems moves the items of a collection whose position is
+// between from and to (or from onwards when to is 0) by delta places.
+func (db *memoryDB) shiftCollectionItems(collectionID int64, from, to, delta int) {
+	for key, item := range db.collectionItems {
+		if key.collectionID != collectionID || item.Position < from || (to != 0 && item.Position > to) {
+			continue
+		}
+		shifted := *item
+		shifted.Position += delta
+		db.collectionItems[key] = &shifted
+	}
+}
+
+func (db *memoryDB) deleteCollection(id int64) {
+	delete(db.collections, id)
+	maps.DeleteFunc(db.collectionItems, func(key collectionItemKey, _ *CollectionItem) bool {
+		return key.collectionID == id
+	})
+}
+
+// copyCollection returns a copy of collection with the number of items whose
+// movie is not in the trash, as the PostgreSQL model counts them.
+func (db *memoryDB) copyCollection(collection *Collection) *Collection {
+	clone := *collection
+	clone.ItemCount = 0
+	for key, item := range db.collectionItems {
+		if key.collectionID != collection.ID {
+			continue
+		}
+		if _, ok := db.copyCollectionItem(item); ok {
+			clone.ItemCount++
+		}
+	}
+	return &clone
+}
+
+// copyCollectionItem returns a copy of item with the current title and year of
+// its movie, reporting false if the movie is in the trash.
+func (db *memoryDB) copyCollectionItem(item *CollectionItem) (*CollectionItem, bool) {
+	movie := db.movies[item.MovieID]
+	if movie.DeletedAt != nil {
+		return nil, false
+	}
+
+	clone := *item
+	clone.Title = movie.Title
+	clone.Year = movie.Year
+	return &clone, true
+}
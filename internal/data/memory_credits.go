@@ -0,0 +1,155 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+type memoryCreditModel struct {
+	db *memoryDB
+}
+
+func (m memoryCreditModel) Insert(ctx context.Context, credit *Credit) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	err := m.db.checkCredit(credit)
+	if err != nil {
+		return err
+	}
+
+	m.db.lastCreditID++
+	credit.ID = m.db.lastCreditID
+	credit.Name = m.db.people[credit.PersonID].Name
+
+	clone := *credit
+	m.db.credits[credit.ID] = &clone
+
+	return nil
+}
+
+func (m memoryCreditModel) Get(ctx context.Context, movieID, id int64) (*Credit, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	credit, ok := m.db.credits[id]
+	if !ok || credit.MovieID != movieID {
+		return nil, ErrRecordNotFound
+	}
+
+	return m.db.copyCredit(credit), nil
+}
+
+func (m memoryCreditModel) GetAllForMovie(ctx context.Context, movieID int64) ([]*Credit, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	credits := []*Credit{}
+	for _, credit := range m.db.credits {
+		if credit.MovieID == movieID {
+			credits = append(credits, m.db.copyCredit(credit))
+		}
+	}
+
+	roles := []string{CreditDirector, CreditWriter, CreditCast}
+	slices.SortFunc(credits, func(a, b *Credit) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(roles, a.Role), slices.Index(roles, b.Role)),
+			cmp.Compare(a.Billing, b.Billing),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	return credits, nil
+}
+
+func (m memoryCreditModel) Update(ctx context.Context, credit *Credit) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.credits[credit.ID]
+	if !ok || stored.MovieID != credit.MovieID {
+		return ErrEditConflict
+	}
+
+	err := m.db.checkCredit(credit)
+	if err != nil {
+		return err
+	}
+
+	credit.Name = m.db.people[credit.PersonID].Name
+
+	clone := *credit
+	m.db.credits[credit.ID] = &clone
+
+	return nil
+}
+
+func (m memoryCreditModel) Delete(ctx context.Context, movieID, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	credit, ok := m.db.credits[id]
+	if !ok || credit.MovieID != movieID {
+		return ErrRecordNotFound
+	}
+
+	delete(m.db.credits, id)
+
+	return nil
+}
+
+// checkCredit enforces the foreign key to people and the uniqueness of a
+// person's role and character within a movie.
+func (db *memoryDB) checkCredit(credit *Credit) error {
+	if _, ok := db.people[credit.PersonID]; !ok {
+		return ErrRecordNotFound
+	}
+
+	for _, other := range db.credits {
+		if other.ID != credit.ID && other.MovieID == credit.MovieID && other.PersonID == credit.PersonID &&
+			other.Role == credit.Role && other.Character == credit.Character {
+			return ErrDuplicateCredit
+		}
+	}
+
+	return nil
+}
+
+func (db *memoryDB) hasCredit(movieID, personID int64) bool {
+	for _, credit := range db.credits {
+		if credit.MovieID == movieID && credit.PersonID == personID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyCredit returns a copy of credit with the current name of its person, as
+// the join in the PostgreSQL model would.
+func (db *memoryDB) copyCredit(credit *Credit) *Credit {
+	clone := *credit
+	clone.Name = db.people[credit.PersonID].Name
+	return &clone
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// defaultGenres mirrors the vocabulary seeded by the create_genres migration.
+var defaultGenres = []*Genre{
+	{Slug: "action", Name: "Action", Aliases: []string{}},
+	{Slug: "adventure", Name: "Adventure", Aliases: []string{}},
+	{Slug: "animation", Name: "Animation", Aliases: []string{"animated", "cartoon"}},
+	{Slug: "biography", Name: "Biography", Aliases: []string{"biopic", "biographical"}},
+	{Slug: "comedy", Name: "Comedy", Aliases: []string{"comedies"}},
+	{Slug: "crime", Name: "Crime", Aliases: []string{}},
+	{Slug: "documentary", Name: "Documentary", Aliases: []string{"doc", "documentaries"}},
+	{Slug: "drama", Name: "Drama", Aliases: []string{"dramas"}},
+	{Slug: "family", Name: "Family", Aliases: []string{}},
+	{Slug: "fantasy", Name: "Fantasy", Aliases: []string{}},
+	{Slug: "history", Name: "History", Aliases: []string{"historical"}},
+	{Slug: "horror", Name: "Horror", Aliases: []string{}},
+	{Slug: "music", Name: "Music", Aliases: []string{}},
+	{Slug: "musical", Name: "Musical", Aliases: []string{"musicals"}},
+	{Slug: "mystery", Name: "Mystery", Aliases: []string{}},
+	{Slug: "romance", Name: "Romance", Aliases: []string{"romantic"}},
+	{Slug: "sci-fi", Name: "Science Fiction", Aliases: []string{"science-fiction", "scifi", "sf"}},
+	{Slug: "sport", Name: "Sport", Aliases: []string{"sports"}},
+	{Slug: "thriller", Name: "Thriller", Aliases: []string{}},
+	{Slug: "war", Name: "War", Aliases: []string{}},
+	{Slug: "western", Name: "Western", Aliases: []string{"westerns"}},
+}
+
+type memoryGenreModel struct {
+	db *memoryDB
+}
+
+func (m memoryGenreModel) Insert(ctx context.Context, genre *Genre) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if m.db.genreTaken(genre) {
+		return ErrDuplicateGenre
+	}
+
+	m.db.lastGenreID++
+	genre.ID = m.db.lastGenreID
+	genre.CreatedAt = memoryNow()
+	genre.Version = 1
+
+	m.db.genres[genre.Slug] = copyGenre(genre)
+
+	return nil
+}
+
+func (m memoryGenreModel) Get(ctx context.Context, slug string) (*Genre, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	genre, ok := m.db.genres[slug]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	return copyGenre(genre), nil
+}
+
+func (m memoryGenreModel) GetAll(ctx context.Context) ([]*Genre, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	genres := []*Genre{}
+	for _, genre := range m.db.genres {
+		genres = append(genres, copyGenre(genre))
+	}
+
+	slices.SortFunc(genres, func(a, b *Genre) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+
+	return genres, nil
+}
+
+func (m memoryGenreModel) Update(ctx context.Context, genre *Genre) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if m.db.genreTaken(genre) {
+		return ErrDuplicateGenre
+	}
+
+	stored, ok := m.db.genres[genre.Slug]
+	if !ok || stored.Version != genre.Version {
+		return ErrEditConflict
+	}
+
+	genre.Version++
+	updated := copyGenre(genre)
+	updated.ID = stored.ID
+	updated.CreatedAt = stored.CreatedAt
+	m.db.genres[genre.Slug] = updated
+
+	return nil
+}
+
+func (m memoryGenreModel) Delete(ctx context.Context, slug string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.genres[slug]; !ok {
+		return ErrRecordNotFound
+	}
+
+	for _, movie := range m.db.movies {
+		if slices.Contains(movie.Genres, slug) {
+			return ErrGenreInUse
+		}
+	}
+
+	delete(m.db.genres, slug)
+
+	return nil
+}
+
+func (db *memoryDB) genreTaken(genre *Genre) bool {
+	keys := append([]string{genre.Slug}, genre.Aliases...)
+
+	for _, other := range db.genres {
+		if other.ID == genre.ID {
+			continue
+		}
+		if slices.Contains(keys, other.Slug) || slices.ContainsFunc(other.Aliases, func(alias string) bool {
+			return slices.Contains(keys, alias)
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyGenre(genre *Genre) *Genre {
+	clone := *genre
+	clone.Aliases = slices.Clone(genre.Aliases)
+	return &clone
+}
+
+// genresExist reports whether every slug names a genre, which movie writes
+// check under the same lock that GenreModel.Delete takes.
+func (db *memoryDB) genresExist(slugs []string) bool {
+	for _, slug := range slugs {
+		if _, ok := db.genres[slug]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
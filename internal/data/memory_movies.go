@@ -0,0 +1,509 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type memoryMovieModel struct {
+	db *memoryDB
+}
+
+func (m memoryMovieModel) Insert(ctx context.Context, movie *Movie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if !m.db.genresExist(movie.Genres) {
+		return ErrUnknownGenre
+	}
+
+	m.db.lastMovieID++
+	movie.ID = m.db.lastMovieID
+	movie.CreatedAt = memoryNow()
+	movie.Version = 1
+
+	m.db.movies[movie.ID] = copyMovie(movie)
+
+	return nil
+}
+
+func (m memoryMovieModel) Get(ctx context.Context, id int64) (*Movie, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	movie, ok := m.db.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	return copyMovie(movie), nil
+}
+
+func (m memoryMovieModel) Update(ctx context.Context, movie *Movie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.movies[movie.ID]
+	if !ok || stored.Version != movie.Version || stored.DeletedAt != nil {
+		return ErrEditConflict
+	}
+
+	if !m.db.genresExist(movie.Genres) {
+		return ErrUnknownGenre
+	}
+
+	movie.Version++
+	movie.AverageRating, movie.RatingCount = stored.AverageRating, stored.RatingCount
+	updated := copyMovie(movie)
+	updated.CreatedAt = stored.CreatedAt
+	m.db.movies[movie.ID] = updated
+
+	return nil
+}
+
+func (m memoryMovieModel) Delete(ctx context.Context, movie *Movie) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.movies[movie.ID]
+	if !ok || stored.Version != movie.Version || stored.DeletedAt != nil {
+		return ErrEditConflict
+	}
+
+	deleted := copyMovie(stored)
+	deletedAt := memoryNow()
+	deleted.DeletedAt = &deletedAt
+	deleted.Version++
+	m.db.movies[movie.ID] = deleted
+
+	movie.Version = deleted.Version
+	movie.DeletedAt = &deletedAt
+
+	return nil
+}
+
+func (m memoryMovieModel) Restore(ctx context.Context, id int64) (*Movie, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.movies[id]
+	if !ok || stored.DeletedAt == nil {
+		return nil, ErrRecordNotFound
+	}
+
+	restored := copyMovie(stored)
+	restored.DeletedAt = nil
+	restored.Version++
+	m.db.movies[id] = restored
+
+	return copyMovie(restored), nil
+}
+
+func (m memoryMovieModel) Purge(ctx context.Context, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.movies[id]
+	if !ok || stored.DeletedAt == nil {
+		return ErrRecordNotFound
+	}
+
+	m.db.deleteMovie(id)
+
+	return nil
+}
+
+func (m memoryMovieModel) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	var ids []int64
+	for id, movie := range m.db.movies {
+		if movie.DeletedAt != nil && movie.DeletedAt.Before(before) {
+			m.db.deleteMovie(id)
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (m memoryMovieModel) GetAll(ctx context.Context, filter MovieFilter, filters Filters) ([]*Movie, Metadata, error) {
+	terms := searchTerms(filter.Title)
+
+	movies, metadata, err := m.list(ctx, filters, filter.matcher(m.db), func(movie *Movie) float64 {
+		return searchRank(movie.Title, terms)
+	})
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	if len(terms) > 0 {
+		for _, movie := range movies {
+			movie.Highlight = highlightTerms(movie.Title, terms)
+		}
+	}
+
+	return movies, metadata, nil
+}
+
+func (m memoryMovieModel) GetAllDeleted(ctx context.Context, filters Filters) ([]*Movie, Metadata, error) {
+	return m.list(ctx, filters, func(movie *Movie) bool {
+		return movie.DeletedAt != nil
+	}, nil)
+}
+
+func (m memoryMovieModel) list(ctx context.Context, filters Filters, match func(*Movie) bool, rank func(*Movie) float64) ([]*Movie, Metadata, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	c, err := filters.cursor()
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	matches := []*Movie{}
+	for _, movie := range m.db.movies {
+		if match(movie) {
+			matches = append(matches, movie)
+		}
+	}
+
+	column, direction := filters.sortColumn(), filters.sortDirection()
+	order := func(a, b *Movie) int {
+		var result int
+		if column == "relevance" {
+			result = cmp.Compare(rank(b), rank(a))
+		} else {
+			result = compareMovies(a, b, column)
+			if direction == "DESC" {
+				result = -result
+			}
+		}
+		if result == 0 {
+			result = cmp.Compare(a.ID, b.ID)
+		}
+		return result
+	}
+
+	totalRecords := len(matches)
+	start := filters.offset()
+
+	if c != nil {
+		position, err := cursorMovie(column, c)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		matches = slices.DeleteFunc(matches, func(movie *Movie) bool {
+			if c.Backward {
+				return order(movie, position) >= 0
+			}
+			return order(movie, position) <= 0
+		})
+		if c.Backward {
+			forward := order
+			order = func(a, b *Movie) int { return forward(b, a) }
+		}
+		start = 0
+	}
+
+	slices.SortFunc(matches, order)
+
+	start = min(start, len(matches))
+	end := min(start+filters.limit()+1, len(matches))
+
+	movies := make([]*Movie, 0, end-start)
+	for _, movie := range matches[start:end] {
+		movies = append(movies, copyMovie(movie))
+	}
+
+	if c == nil && len(movies) == 0 {
+		totalRecords = 0
+	}
+
+	movies, metadata := paginateMovies(filters, c, totalRecords, movies)
+
+	return movies, metadata, nil
+}
+
+func (m memoryMovieModel) Export(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	match := filter.matcher(m.db)
+
+	m.db.rlock()
+	var movies []*Movie
+	for _, movie := range m.db.movies {
+		if match(movie) {
+			movies = append(movies, copyMovie(movie))
+		}
+	}
+	m.db.runlock()
+
+	slices.SortFunc(movies, func(a, b *Movie) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, movie := range movies {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		err := fn(movie)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matcher returns a predicate that reads the credits in db for the person
+// filter, so it must only be called with db locked.
+func (f MovieFilter) matcher(db *memoryDB) func(*Movie) bool {
+	var predicates []func(*Movie) bool
+
+	if f.Title != "" {
+		terms := searchTerms(f.Title)
+		predicates = append(predicates, func(movie *Movie) bool { return matchesTitle(movie.Title, terms) })
+	}
+	if len(f.Genres) > 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return containsAll(movie.Genres, f.Genres) })
+	}
+	if len(f.GenresAny) > 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return containsAny(movie.Genres, f.GenresAny) })
+	}
+	if len(f.GenresNone) > 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return !containsAny(movie.Genres, f.GenresNone) })
+	}
+	if f.YearFrom != 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return int(movie.Year) >= f.YearFrom })
+	}
+	if f.YearTo != 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return int(movie.Year) <= f.YearTo })
+	}
+	if f.RuntimeMin != 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return movie.Runtime >= f.RuntimeMin })
+	}
+	if f.RuntimeMax != 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return movie.Runtime <= f.RuntimeMax })
+	}
+	if !f.CreatedAfter.IsZero() {
+		predicates = append(predicates, func(movie *Movie) bool { return movie.CreatedAt.After(f.CreatedAfter) })
+	}
+	if !f.CreatedBefore.IsZero() {
+		predicates = append(predicates, func(movie *Movie) bool { return movie.CreatedAt.Before(f.CreatedBefore) })
+	}
+	if f.PersonID != 0 {
+		predicates = append(predicates, func(movie *Movie) bool { return db.hasCredit(movie.ID, f.PersonID) })
+	}
+
+	return func(movie *Movie) bool {
+		if movie.DeletedAt != nil {
+			return false
+		}
+		if len(predicates) == 0 {
+			return true
+		}
+
+		if f.Match == MatchAny {
+			return slices.ContainsFunc(predicates, func(predicate func(*Movie) bool) bool {
+				return predicate(movie)
+			})
+		}
+
+		for _, predicate := range predicates {
+			if !predicate(movie) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func matchesTitle(title string, terms []string) bool {
+	words := searchTerms(title)
+	for _, term := range terms {
+		if !slices.ContainsFunc(words, hasPrefix(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchRank scores a title by the search terms it contains, counting whole
+// words above prefix matches.
+func searchRank(title string, terms []string) float64 {
+	var rank float64
+	for _, word := range searchTerms(title) {
+		for _, term := range terms {
+			switch {
+			case word == term:
+				rank++
+			case strings.HasPrefix(word, term):
+				rank += 0.5
+			}
+		}
+	}
+	return rank
+}
+
+func highlightTerms(title string, terms []string) string {
+	return searchWordRX.ReplaceAllStringFunc(title, func(word string) string {
+		if slices.ContainsFunc(terms, func(term string) bool { return strings.HasPrefix(strings.ToLower(word), term) }) {
+			return "<b>" + word + "</b>"
+		}
+		return word
+	})
+}
+
+func hasPrefix(prefix string) func(string) bool {
+	return func(s string) bool {
+		return strings.HasPrefix(s, prefix)
+	}
+}
+
+func containsAll(values, required []string) bool {
+	for _, r := range required {
+		if !slices.Contains(values, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, c := range candidates {
+		if slices.Contains(values, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func cursorMovie(column string, c *cursor) (*Movie, error) {
+	movie := &Movie{ID: c.ID}
+
+	switch column {
+	case "id":
+	case "title":
+		movie.Title = c.Value
+	case "year":
+		year, err := strconv.ParseInt(c.Value, 10, 32)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		movie.Year = int32(year)
+	case "runtime":
+		runtime, err := strconv.ParseInt(c.Value, 10, 32)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		movie.Runtime = Runtime(runtime)
+	case "average_rating":
+		averageRating, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		movie.AverageRating = averageRating
+	case "deleted_at":
+		deletedAt, err := time.Parse(time.RFC3339, c.Value)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		movie.DeletedAt = &deletedAt
+	default:
+		panic("unsupported sort column: " + column)
+	}
+
+	return movie, nil
+}
+
+func compareMovies(a, b *Movie, column string) int {
+	switch column {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "year":
+		return cmp.Compare(a.Year, b.Year)
+	case "runtime":
+		return cmp.Compare(a.Runtime, b.Runtime)
+	case "average_rating":
+		return cmp.Compare(a.AverageRating, b.AverageRating)
+	case "deleted_at":
+		return a.DeletedAt.Compare(*b.DeletedAt)
+	default:
+		panic("unsupported sort column: " + column)
+	}
+}
+
+func (m memoryMovieModel) Facets(ctx context.Context, filter MovieFilter, yearInterval int) (*MovieFacets, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	match := filter.matcher(m.db)
+	counts := facetCounts{genres: make(map[string]int), years: make(map[int]int), runtimes: make(map[int]int)}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	for _, movie := range m.db.movies {
+		if !match(movie) {
+			continue
+		}
+
+		counts.total++
+		for _, genre := range movie.Genres {
+			counts.genres[genre]++
+		}
+		counts.years[int(movie.Year)/yearInterval*yearInterval]++
+		counts.runtimes[runtimeBucket(movie.Runtime)]++
+	}
+
+	return counts.facets(), nil
+}
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type memoryPersonModel struct {
+	db *memoryDB
+}
+
+func (m memoryPersonModel) Insert(ctx context.Context, person *Person) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	m.db.lastPersonID++
+	person.ID = m.db.lastPersonID
+	person.CreatedAt = memoryNow()
+	person.Version = 1
+
+	clone := *person
+	m.db.people[person.ID] = &clone
+
+	return nil
+}
+
+func (m memoryPersonModel) Get(ctx context.Context, id int64) (*Person, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	person, ok := m.db.people[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	clone := *person
+	return &clone, nil
+}
+
+func (m memoryPersonModel) GetAll(ctx context.Context, name string, filters Filters) ([]*Person, Metadata, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	matches := []*Person{}
+	for _, person := range m.db.people {
+		if strings.Contains(strings.ToLower(person.Name), strings.ToLower(name)) {
+			matches = append(matches, person)
+		}
+	}
+
+	column, direction := filters.sortColumn(), filters.sortDirection()
+	slices.SortFunc(matches, func(a, b *Person) int {
+		var result int
+		switch column {
+		case "name":
+			result = strings.Compare(a.Name, b.Name)
+		default:
+			result = cmp.Compare(a.ID, b.ID)
+		}
+		if direction == "DESC" {
+			result = -result
+		}
+		if result == 0 {
+			result = cmp.Compare(a.ID, b.ID)
+		}
+		return result
+	})
+
+	start := min(filters.offset(), len(matches))
+	end := min(start+filters.limit(), len(matches))
+
+	people := make([]*Person, 0, end-start)
+	for _, person := range matches[start:end] {
+		clone := *person
+		people = append(people, &clone)
+	}
+
+	metadata := calculateMetadata(len(matches), filters.Page, filters.PageSize)
+	if len(people) == 0 {
+		metadata = Metadata{}
+	}
+
+	return people, metadata, nil
+}
+
+func (m memoryPersonModel) Update(ctx context.Context, person *Person) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.people[person.ID]
+	if !ok || stored.Version != person.Version {
+		return ErrEditConflict
+	}
+
+	person.Version++
+	updated := *person
+	updated.CreatedAt = stored.CreatedAt
+	m.db.people[person.ID] = &updated
+
+	return nil
+}
+
+func (m memoryPersonModel) Delete(ctx context.Context, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.people[id]; !ok {
+		return ErrRecordNotFound
+	}
+
+	delete(m.db.people, id)
+	maps.DeleteFunc(m.db.credits, func(_ int64, credit *Credit) bool {
+		return credit.PersonID == id
+	})
+
+	return nil
+}
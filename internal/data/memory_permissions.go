@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+type memoryPermissionModel struct {
+	db *memoryDB
+}
+
+func (m memoryPermissionModel) GetAllForUser(ctx context.Context, userID int64) (Permissions, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	if _, ok := m.db.users[userID]; !ok {
+		return nil, nil
+	}
+
+	return slices.Clone(Permissions(m.db.userPerms[userID])), nil
+}
+
+func (m memoryPermissionModel) AddForUser(ctx context.Context, userID int64, codes ...string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.users[userID]; !ok {
+		return errors.New("permissions reference unknown user")
+	}
+
+	granted := slices.Clone(m.db.userPerms[userID])
+	for _, code := range m.db.permissions {
+		if !slices.Contains(codes, code) {
+			continue
+		}
+		if slices.Contains(granted, code) {
+			return errors.New("permission already granted to user")
+		}
+		granted = append(granted, code)
+	}
+
+	m.db.userPerms[userID] = granted
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"math"
+)
+
+type ratingKey struct {
+	movieID int64
+	userID  int64
+}
+
+type memoryRatingModel struct {
+	db *memoryDB
+}
+
+func (m memoryRatingModel) Get(ctx context.Context, movieID, userID int64) (*Rating, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	rating, ok := m.db.ratings[ratingKey{movieID, userID}]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	clone := *rating
+	return &clone, nil
+}
+
+func (m memoryRatingModel) Insert(ctx context.Context, rating *Rating) error {
+	return m.update(ctx, rating.MovieID, func() error {
+		if _, ok := m.db.ratings[ratingKey{rating.MovieID, rating.UserID}]; ok {
+			return ErrDuplicateRating
+		}
+
+		rating.CreatedAt = memoryNow()
+		rating.UpdatedAt = rating.CreatedAt
+
+		clone := *rating
+		m.db.ratings[ratingKey{rating.MovieID, rating.UserID}] = &clone
+		return nil
+	})
+}
+
+func (m memoryRatingModel) Set(ctx context.Context, rating *Rating) error {
+	return m.update(ctx, rating.MovieID, func() error {
+		rating.CreatedAt = memoryNow()
+		rating.UpdatedAt = rating.CreatedAt
+		if stored, ok := m.db.ratings[ratingKey{rating.MovieID, rating.UserID}]; ok {
+			rating.CreatedAt = stored.CreatedAt
+		}
+
+		clone := *rating
+		m.db.ratings[ratingKey{rating.MovieID, rating.UserID}] = &clone
+		return nil
+	})
+}
+
+func (m memoryRatingModel) Delete(ctx context.Context, movieID, userID int64) error {
+	return m.update(ctx, movieID, func() error {
+		if _, ok := m.db.ratings[ratingKey{movieID, userID}]; !ok {
+			return ErrRecordNotFound
+		}
+
+		delete(m.db.ratings, ratingKey{movieID, userID})
+		return nil
+	})
+}
+
+// update runs fn with db locked and recalculates the rating aggregates of the
+// movie.
+func (m memoryRatingModel) update(ctx context.Context, movieID int64, fn func() error) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	movie, ok := m.db.movies[movieID]
+	if !ok || movie.DeletedAt != nil {
+		return ErrRecordNotFound
+	}
+
+	err := fn()
+	if err != nil {
+		return err
+	}
+
+	m.db.updateRatingAggregates(movieID)
+
+	return nil
+}
+
+// updateRatingAggregates replaces the stored movie with a copy carrying the
+// current average and count of its ratings.
+func (db *memoryDB) updateRatingAggregates(movieID int64) {
+	var total, count int32
+	for key, rating := range db.ratings {
+		if key.movieID == movieID {
+			total += rating.Score
+			count++
+		}
+	}
+
+	movie := copyMovie(db.movies[movieID])
+	movie.RatingCount = count
+	movie.AverageRating = 0
+	if count > 0 {
+		movie.AverageRating = math.Round(float64(total)/float64(count)*100) / 100
+	}
+	db.movies[movieID] = movie
+}
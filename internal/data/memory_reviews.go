@@ -0,0 +1,143 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+type memoryReviewModel struct {
+	db *memoryDB
+}
+
+func (m memoryReviewModel) Insert(ctx context.Context, review *Review) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	for _, other := range m.db.reviews {
+		if other.MovieID == review.MovieID && other.UserID == review.UserID {
+			return ErrDuplicateReview
+		}
+	}
+
+	m.db.lastReviewID++
+	review.ID = m.db.lastReviewID
+	review.CreatedAt = memoryNow()
+	review.UpdatedAt = review.CreatedAt
+	review.Version = 1
+	review.Author = m.db.users[review.UserID].Name
+
+	clone := *review
+	m.db.reviews[review.ID] = &clone
+
+	return nil
+}
+
+func (m memoryReviewModel) Get(ctx context.Context, movieID, id int64) (*Review, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	review, ok := m.db.reviews[id]
+	if !ok || review.MovieID != movieID {
+		return nil, ErrRecordNotFound
+	}
+
+	return m.db.copyReview(review), nil
+}
+
+func (m memoryReviewModel) GetAllForMovie(ctx context.Context, movieID int64, includeHidden bool, filters Filters) ([]*Review, Metadata, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	matches := []*Review{}
+	for _, review := range m.db.reviews {
+		if review.MovieID == movieID && (includeHidden || !review.Hidden) {
+			matches = append(matches, review)
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b *Review) int {
+		result := a.CreatedAt.Compare(b.CreatedAt)
+		if filters.sortDirection() == "DESC" {
+			result = -result
+		}
+		return cmp.Or(result, cmp.Compare(a.ID, b.ID))
+	})
+
+	start := min(filters.offset(), len(matches))
+	end := min(start+filters.limit(), len(matches))
+
+	reviews := make([]*Review, 0, end-start)
+	for _, review := range matches[start:end] {
+		reviews = append(reviews, m.db.copyReview(review))
+	}
+
+	metadata := calculateMetadata(len(matches), filters.Page, filters.PageSize)
+	if len(reviews) == 0 {
+		metadata = Metadata{}
+	}
+
+	return reviews, metadata, nil
+}
+
+func (m memoryReviewModel) Update(ctx context.Context, review *Review) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.reviews[review.ID]
+	if !ok || stored.MovieID != review.MovieID || stored.Version != review.Version {
+		return ErrEditConflict
+	}
+
+	if review.Body != stored.Body {
+		review.UpdatedAt = memoryNow()
+	}
+	review.Version++
+
+	clone := *review
+	m.db.reviews[review.ID] = &clone
+
+	return nil
+}
+
+func (m memoryReviewModel) Delete(ctx context.Context, movieID, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	review, ok := m.db.reviews[id]
+	if !ok || review.MovieID != movieID {
+		return ErrRecordNotFound
+	}
+
+	delete(m.db.reviews, id)
+
+	return nil
+}
+
+// copyReview returns a copy of review with the current name of its author, as
+// the join in the PostgreSQL model would.
+func (db *memoryDB) copyReview(review *Review) *Review {
+	clone := *review
+	clone.Author = db.users[review.UserID].Name
+	return &clone
+}
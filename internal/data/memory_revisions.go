@@ -0,0 +1,95 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+type memoryMovieRevisionModel struct {
+	db *memoryDB
+}
+
+func (m memoryMovieRevisionModel) Insert(ctx context.Context, revision *MovieRevision) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.movies[revision.MovieID]; !ok {
+		return errors.New("revision references unknown movie")
+	}
+
+	revisions := m.db.revisions[revision.MovieID]
+	for _, existing := range revisions {
+		if existing.Version == revision.Version {
+			return errors.New("duplicate movie revision")
+		}
+	}
+
+	revision.CreatedAt = memoryNow()
+	m.db.revisions[revision.MovieID] = append(slices.Clip(revisions), copyRevision(revision))
+
+	return nil
+}
+
+func (m memoryMovieRevisionModel) Get(ctx context.Context, movieID int64, version int32) (*MovieRevision, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	for _, revision := range m.db.revisions[movieID] {
+		if revision.Version == version {
+			return copyRevision(revision), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+func (m memoryMovieRevisionModel) GetAllForMovie(ctx context.Context, movieID int64, filters Filters) ([]*MovieRevision, Metadata, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	matches := slices.Clone(m.db.revisions[movieID])
+	slices.SortFunc(matches, func(a, b *MovieRevision) int {
+		if filters.sortDirection() == "DESC" {
+			return int(b.Version - a.Version)
+		}
+		return int(a.Version - b.Version)
+	})
+
+	start := min(filters.offset(), len(matches))
+	end := min(start+filters.limit(), len(matches))
+
+	revisions := make([]*MovieRevision, 0, end-start)
+	for _, revision := range matches[start:end] {
+		revisions = append(revisions, copyRevision(revision))
+	}
+
+	metadata := calculateMetadata(len(matches), filters.Page, filters.PageSize)
+	if len(revisions) == 0 {
+		metadata = Metadata{}
+	}
+
+	return revisions, metadata, nil
+}
+
+func copyRevision(revision *MovieRevision) *MovieRevision {
+	clone := *revision
+	clone.Genres = slices.Clone(revision.Genres)
+	if revision.UserID != nil {
+		userID := *revision.UserID
+		clone.UserID = &userID
+	}
+	return &clone
+}
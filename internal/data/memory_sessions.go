@@ -0,0 +1,196 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+func (m memoryTokenModel) NewSession(ctx context.Context, userID int64, accessTTL, refreshTTL time.Duration, ip, userAgent string) (*Token, *Token, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	access, refresh, err := generateSessionPair(userID, accessTTL, refreshTTL, ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	err = m.db.insertToken(refresh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh.FamilyID = refresh.ID
+	m.db.storeToken(refresh)
+
+	access.FamilyID = refresh.ID
+	err = m.db.insertToken(access)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+func (m memoryTokenModel) Rotate(ctx context.Context, refreshPlaintext string, accessTTL time.Duration, ip, userAgent string) (*Token, *Token, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	hash := HashToken(refreshPlaintext)
+	stored, ok := m.db.tokens[string(hash)]
+	if !ok || stored.Scope != ScopeRefresh || !stored.Expiry.After(time.Now()) {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	if stored.LastUsedAt != nil {
+		m.db.deleteTokenFamily(stored.FamilyID)
+		return nil, nil, ErrTokenReused
+	}
+
+	access, refresh, err := generateSessionPair(stored.UserID, accessTTL, time.Until(stored.Expiry), ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := memoryNow()
+	spent := *stored
+	spent.LastUsedAt = &now
+	m.db.tokens[string(hash)] = &spent
+
+	for key, token := range m.db.tokens {
+		if token.FamilyID == stored.FamilyID && token.Scope == ScopeAuthentication {
+			delete(m.db.tokens, key)
+		}
+	}
+
+	refresh.Expiry = stored.Expiry
+	access.FamilyID = stored.FamilyID
+	refresh.FamilyID = stored.FamilyID
+
+	err = m.db.insertToken(refresh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = m.db.insertToken(access)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+func (m memoryTokenModel) GetSessionsForUser(ctx context.Context, userID int64, currentHash []byte) ([]*Session, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	sessions := []*Session{}
+	for _, root := range m.db.tokens {
+		if root.UserID != userID || root.Scope != ScopeRefresh || root.ID != root.FamilyID || !root.Expiry.After(time.Now()) {
+			continue
+		}
+
+		session := &Session{
+			ID:        root.ID,
+			CreatedAt: root.CreatedAt,
+			Expiry:    root.Expiry,
+			IP:        root.IP,
+			UserAgent: root.UserAgent,
+		}
+		for key, token := range m.db.tokens {
+			if token.FamilyID != root.ID {
+				continue
+			}
+			if token.LastUsedAt != nil && (session.LastUsedAt == nil || token.LastUsedAt.After(*session.LastUsedAt)) {
+				lastUsedAt := *token.LastUsedAt
+				session.LastUsedAt = &lastUsedAt
+			}
+			if key == string(currentHash) {
+				session.Current = true
+			}
+		}
+		sessions = append(sessions, session)
+	}
+
+	lastActive := func(session *Session) time.Time {
+		if session.LastUsedAt != nil {
+			return *session.LastUsedAt
+		}
+		return session.CreatedAt
+	}
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		return cmp.Or(lastActive(b).Compare(lastActive(a)), cmp.Compare(b.ID, a.ID))
+	})
+
+	return sessions, nil
+}
+
+func (m memoryTokenModel) DeleteSession(ctx context.Context, userID, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	for _, token := range m.db.tokens {
+		if token.FamilyID == id && token.UserID == userID {
+			m.db.deleteTokenFamily(id)
+			return nil
+		}
+	}
+
+	return ErrRecordNotFound
+}
+
+func (m memoryTokenModel) DeleteSessionByHash(ctx context.Context, hash []byte) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	token, err := m.db.deleteTokenByHash(ScopeAuthentication, hash)
+	if err != nil {
+		return err
+	}
+
+	m.db.deleteTokenFamily(token.FamilyID)
+
+	return nil
+}
+
+func (m memoryTokenModel) Touch(ctx context.Context, hash []byte, interval time.Duration) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	token, ok := m.db.tokens[string(hash)]
+	if !ok || (token.LastUsedAt != nil && token.LastUsedAt.After(time.Now().Add(-interval))) {
+		return nil
+	}
+
+	now := memoryNow()
+	touched := *token
+	touched.LastUsedAt = &now
+	m.db.tokens[string(hash)] = &touched
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+// suggestThreshold mirrors pg_trgm's default word_similarity_threshold.
+const suggestThreshold = 0.6
+
+func (m memoryMovieModel) Suggest(ctx context.Context, q string, limit int) ([]*MovieSuggestion, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		movie      *Movie
+		prefix     bool
+		similarity float64
+	}
+
+	lower := strings.ToLower(q)
+
+	m.db.rlock()
+	var matches []match
+	for _, movie := range m.db.movies {
+		if movie.DeletedAt != nil {
+			continue
+		}
+
+		candidate := match{
+			movie:      movie,
+			prefix:     strings.HasPrefix(strings.ToLower(movie.Title), lower),
+			similarity: wordSimilarity(q, movie.Title),
+		}
+		if candidate.prefix || candidate.similarity >= suggestThreshold {
+			matches = append(matches, candidate)
+		}
+	}
+	m.db.runlock()
+
+	slices.SortFunc(matches, func(a, b match) int {
+		if a.prefix != b.prefix {
+			if a.prefix {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.similarity, a.similarity); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.movie.Title, b.movie.Title); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.movie.ID, b.movie.ID)
+	})
+
+	suggestions := []*MovieSuggestion{}
+	for _, match := range matches[:min(limit, len(matches))] {
+		suggestions = append(suggestions, &MovieSuggestion{ID: match.movie.ID, Title: match.movie.Title, Year: match.movie.Year})
+	}
+
+	return suggestions, nil
+}
+
+// wordSimilarity approximates pg_trgm's word_similarity: the share of the
+// trigrams of q found in the best matching word of text.
+func wordSimilarity(q, text string) float64 {
+	queryTrigrams := trigrams(q)
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for _, word := range searchTerms(text) {
+		wordTrigrams := trigrams(word)
+
+		shared := 0
+		for trigram := range queryTrigrams {
+			if wordTrigrams[trigram] {
+				shared++
+			}
+		}
+
+		best = max(best, float64(shared)/float64(len(queryTrigrams)))
+	}
+
+	return best
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range searchTerms(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+)
+
+type memoryTokenModel struct {
+	db *memoryDB
+}
+
+func (m memoryTokenModel) New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.Insert(ctx, token)
+	return token, err
+}
+
+func (m memoryTokenModel) Insert(ctx context.Context, token *Token) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	return m.db.insertToken(token)
+}
+
+func (m memoryTokenModel) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	for key, token := range m.db.tokens {
+		if token.Scope == scope && token.UserID == userID {
+			delete(m.db.tokens, key)
+		}
+	}
+
+	return nil
+}
+
+func (m memoryTokenModel) DeleteByHash(ctx context.Context, scope string, hash []byte) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	_, err := m.db.deleteTokenByHash(scope, hash)
+	return err
+}
+
+func (db *memoryDB) insertToken(token *Token) error {
+	if _, ok := db.users[token.UserID]; !ok {
+		return errors.New("token references unknown user")
+	}
+
+	key := string(token.Hash)
+	if _, exists := db.tokens[key]; exists {
+		return errors.New("duplicate token hash")
+	}
+
+	db.lastTokenID++
+	token.ID = db.lastTokenID
+	token.CreatedAt = memoryNow()
+
+	db.storeToken(token)
+
+	return nil
+}
+
+func (db *memoryDB) storeToken(token *Token) {
+	stored := *token
+	stored.Plaintext = ""
+	stored.Hash = slices.Clone(token.Hash)
+	stored.Expiry = token.Expiry.Truncate(time.Second)
+	db.tokens[string(token.Hash)] = &stored
+}
+
+func (db *memoryDB) deleteTokenFamily(familyID int64) {
+	maps.DeleteFunc(db.tokens, func(_ string, token *Token) bool {
+		return familyID != 0 && token.FamilyID == familyID
+	})
+}
+
+func (db *memoryDB) deleteTokenByHash(scope string, hash []byte) (*Token, error) {
+	token, ok := db.tokens[string(hash)]
+	if !ok || token.Scope != scope {
+		return nil, ErrRecordNotFound
+	}
+
+	delete(db.tokens, string(hash))
+
+	return token, nil
+}
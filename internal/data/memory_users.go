@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+type memoryUserModel struct {
+	db *memoryDB
+}
+
+func (m memoryUserModel) Insert(ctx context.Context, user *User) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if m.db.emailTaken(user.Email, 0) {
+		return ErrDuplicateEmail
+	}
+
+	m.db.lastUserID++
+	user.ID = m.db.lastUserID
+	user.CreatedAt = memoryNow()
+	user.Version = 1
+
+	m.db.users[user.ID] = copyUser(user)
+
+	return nil
+}
+
+func (m memoryUserModel) Get(ctx context.Context, id int64) (*User, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	return copyUser(user), nil
+}
+
+func (m memoryUserModel) GetByEmail(ctx context.Context, email string) (*User, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	for _, user := range m.db.users {
+		if strings.EqualFold(user.Email, email) {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+func (m memoryUserModel) Update(ctx context.Context, user *User) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	stored, ok := m.db.users[user.ID]
+	if !ok || stored.Version != user.Version {
+		return ErrEditConflict
+	}
+
+	if m.db.emailTaken(user.Email, user.ID) {
+		return ErrDuplicateEmail
+	}
+
+	user.Version++
+	updated := copyUser(user)
+	updated.CreatedAt = stored.CreatedAt
+	m.db.users[user.ID] = updated
+
+	return nil
+}
+
+func (m memoryUserModel) Delete(ctx context.Context, id int64) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	m.db.lock()
+	defer m.db.unlock()
+
+	if _, ok := m.db.users[id]; !ok {
+		return ErrRecordNotFound
+	}
+
+	m.db.deleteUser(id)
+
+	return nil
+}
+
+func (m memoryUserModel) GetForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*User, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	m.db.rlock()
+	defer m.db.runlock()
+
+	token, ok := m.db.tokens[string(HashToken(tokenPlaintext))]
+	if !ok || token.Scope != tokenScope || !token.Expiry.After(time.Now()) {
+		return nil, ErrRecordNotFound
+	}
+
+	user, ok := m.db.users[token.UserID]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	clone := copyUser(user)
+	if token.LastUsedAt != nil {
+		lastUsedAt := *token.LastUsedAt
+		clone.TokenLastUsedAt = &lastUsedAt
+	}
+
+	return clone, nil
+}
+
+func (db *memoryDB) emailTaken(email string, exceptUserID int64) bool {
+	for _, user := range db.users {
+		if user.ID != exceptUserID && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUser removes a user and the records referencing them, as the foreign
+// keys of the PostgreSQL schema do, and recalculates the rating aggregates of
+// the movies they rated.
+func (db *memoryDB) deleteUser(id int64) {
+	delete(db.users, id)
+	delete(db.userPerms, id)
+
+	maps.DeleteFunc(db.tokens, func(_ string, token *Token) bool {
+		return token.UserID == id
+	})
+
+	var rated []int64
+	maps.DeleteFunc(db.ratings, func(key ratingKey, _ *Rating) bool {
+		if key.userID == id {
+			rated = append(rated, key.movieID)
+			return true
+		}
+		return false
+	})
+	for _, movieID := range rated {
+		db.updateRatingAggregates(movieID)
+	}
+
+	maps.DeleteFunc(db.reviews, func(_ int64, review *Review) bool {
+		return review.UserID == id
+	})
+
+	for collectionID, collection := range db.collections {
+		if collection.UserID == id {
+			db.deleteCollection(collectionID)
+		}
+	}
+
+	for movieID, revisions := range db.revisions {
+		authored := func(revision *MovieRevision) bool {
+			return revision.UserID != nil && *revision.UserID == id
+		}
+		if !slices.ContainsFunc(revisions, authored) {
+			continue
+		}
+
+		updated := make([]*MovieRevision, len(revisions))
+		for i, revision := range revisions {
+			if authored(revision) {
+				revision = copyRevision(revision)
+				revision.UserID = nil
+			}
+			updated[i] = revision
+		}
+		db.revisions[movieID] = updated
+	}
+}
@@ -1,27 +1,233 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
+	ErrQueryCanceled  = errors.New("query canceled")
+	ErrQueryTimeout   = errors.New("query timed out")
 )
 
+type MovieStore interface {
+	Insert(ctx context.Context, movie *Movie) error
+	Get(ctx context.Context, id int64) (*Movie, error)
+	Update(ctx context.Context, movie *Movie) error
+	Delete(ctx context.Context, movie *Movie) error
+	Restore(ctx context.Context, id int64) (*Movie, error)
+	Purge(ctx context.Context, id int64) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error)
+	GetAll(ctx context.Context, filter MovieFilter, filters Filters) ([]*Movie, Metadata, error)
+	GetAllDeleted(ctx context.Context, filters Filters) ([]*Movie, Metadata, error)
+	Export(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error
+	Suggest(ctx context.Context, q string, limit int) ([]*MovieSuggestion, error)
+	Facets(ctx context.Context, filter MovieFilter, yearInterval int) (*MovieFacets, error)
+}
+
+type MovieRevisionStore interface {
+	Insert(ctx context.Context, revision *MovieRevision) error
+	Get(ctx context.Context, movieID int64, version int32) (*MovieRevision, error)
+	GetAllForMovie(ctx context.Context, movieID int64, filters Filters) ([]*MovieRevision, Metadata, error)
+}
+
+type UserStore interface {
+	Insert(ctx context.Context, user *User) error
+	Get(ctx context.Context, id int64) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int64) error
+	GetForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*User, error)
+}
+
+type TokenStore interface {
+	New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*Token, error)
+	NewSession(ctx context.Context, userID int64, accessTTL, refreshTTL time.Duration, ip, userAgent string) (*Token, *Token, error)
+	Rotate(ctx context.Context, refreshPlaintext string, accessTTL time.Duration, ip, userAgent string) (*Token, *Token, error)
+	Insert(ctx context.Context, token *Token) error
+	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+	DeleteByHash(ctx context.Context, scope string, hash []byte) error
+	GetSessionsForUser(ctx context.Context, userID int64, currentHash []byte) ([]*Session, error)
+	DeleteSession(ctx context.Context, userID, id int64) error
+	DeleteSessionByHash(ctx context.Context, hash []byte) error
+	Touch(ctx context.Context, hash []byte, interval time.Duration) error
+}
+
+type PermissionStore interface {
+	GetAllForUser(ctx context.Context, userID int64) (Permissions, error)
+	AddForUser(ctx context.Context, userID int64, codes ...string) error
+}
+
+type GenreStore interface {
+	Insert(ctx context.Context, genre *Genre) error
+	Get(ctx context.Context, slug string) (*Genre, error)
+	GetAll(ctx context.Context) ([]*Genre, error)
+	Update(ctx context.Context, genre *Genre) error
+	Delete(ctx context.Context, slug string) error
+}
+
+type PersonStore interface {
+	Insert(ctx context.Context, person *Person) error
+	Get(ctx context.Context, id int64) (*Person, error)
+	GetAll(ctx context.Context, name string, filters Filters) ([]*Person, Metadata, error)
+	Update(ctx context.Context, person *Person) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type CreditStore interface {
+	Insert(ctx context.Context, credit *Credit) error
+	Get(ctx context.Context, movieID, id int64) (*Credit, error)
+	GetAllForMovie(ctx context.Context, movieID int64) ([]*Credit, error)
+	Update(ctx context.Context, credit *Credit) error
+	Delete(ctx context.Context, movieID, id int64) error
+}
+
+type RatingStore interface {
+	Get(ctx context.Context, movieID, userID int64) (*Rating, error)
+	Insert(ctx context.Context, rating *Rating) error
+	Set(ctx context.Context, rating *Rating) error
+	Delete(ctx context.Context, movieID, userID int64) error
+}
+
+type ReviewStore interface {
+	Insert(ctx context.Context, review *Review) error
+	Get(ctx context.Context, movieID, id int64) (*Review, error)
+	GetAllForMovie(ctx context.Context, movieID int64, includeHidden bool, filters Filters) ([]*Review, Metadata, error)
+	Update(ctx context.Context, review *Review) error
+	Delete(ctx context.Context, movieID, id int64) error
+}
+
+type CollectionStore interface {
+	Insert(ctx context.Context, collection *Collection) error
+	Get(ctx context.Context, id int64) (*Collection, error)
+	GetByShareID(ctx context.Context, shareID string) (*Collection, error)
+	GetAllForUser(ctx context.Context, userID int64) ([]*Collection, error)
+	GetAllPublic(ctx context.Context, filters Filters) ([]*Collection, Metadata, error)
+	Update(ctx context.Context, collection *Collection) error
+	Delete(ctx context.Context, id int64) error
+	GetItems(ctx context.Context, collectionID int64) ([]*CollectionItem, error)
+	GetItem(ctx context.Context, collectionID, movieID int64) (*CollectionItem, error)
+	AddItem(ctx context.Context, item *CollectionItem) error
+	UpdateItem(ctx context.Context, item *CollectionItem) error
+	RemoveItem(ctx context.Context, collectionID, movieID int64) error
+}
+
 type Models struct {
-	Movies      MovieModel
-	Users       UserModel
-	Tokens      TokenModel
-	Permissions PermissionModel
+	Movies      MovieStore
+	Revisions   MovieRevisionStore
+	Users       UserStore
+	Tokens      TokenStore
+	Permissions PermissionStore
+	Genres      GenreStore
+	People      PersonStore
+	Credits     CreditStore
+	Ratings     RatingStore
+	Reviews     ReviewStore
+	Collections CollectionStore
+
+	withTx func(ctx context.Context, fn func(tx Models) error) error
+}
+
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
 }
 
-func NewModels(db *sql.DB) Models {
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+func NewModels(db *sql.DB, timeouts Timeouts) Models {
+	models := newPostgresModels(db, timeouts)
+	models.withTx = func(ctx context.Context, fn func(tx Models) error) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		defer tx.Rollback()
+
+		txModels := newPostgresModels(tx, timeouts)
+		txModels.withTx = func(ctx context.Context, fn func(tx Models) error) error {
+			return fn(txModels)
+		}
+
+		err = fn(txModels)
+		if err != nil {
+			return err
+		}
+
+		return contextErr(ctx, tx.Commit())
+	}
+
+	return models
+}
+
+func newPostgresModels(db dbtx, timeouts Timeouts) Models {
 	return Models{
-		Movies:      MovieModel{DB: db},
-		Users:       UserModel{DB: db},
-		Tokens:      TokenModel{DB: db},
-		Permissions: PermissionModel{DB: db},
+		Movies:      MovieModel{DB: db, Timeouts: timeouts},
+		Revisions:   MovieRevisionModel{DB: db, Timeouts: timeouts},
+		Users:       UserModel{DB: db, Timeouts: timeouts},
+		Tokens:      TokenModel{DB: db, Timeouts: timeouts},
+		Permissions: PermissionModel{DB: db, Timeouts: timeouts},
+		Genres:      GenreModel{DB: db, Timeouts: timeouts},
+		People:      PersonModel{DB: db, Timeouts: timeouts},
+		Credits:     CreditModel{DB: db, Timeouts: timeouts},
+		Ratings:     RatingModel{DB: db, Timeouts: timeouts},
+		Reviews:     ReviewModel{DB: db, Timeouts: timeouts},
+		Collections: CollectionModel{DB: db, Timeouts: timeouts},
+	}
+}
+
+// WithTx runs fn against a copy of the models bound to a single transaction,
+// committing if fn returns nil and rolling back otherwise. Calling WithTx on
+// the models passed to fn joins the transaction that is already open.
+func (m Models) WithTx(ctx context.Context, fn func(tx Models) error) error {
+	return m.withTx(ctx, fn)
+}
+
+// inTx runs fn in a new transaction when db is the connection pool, or
+// directly on db when a model is already bound to a transaction, so that
+// multi-statement model methods are atomic either way.
+func inTx(ctx context.Context, db dbtx, fn func(tx dbtx) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return contextErr(ctx, tx.Commit())
+}
+
+func contextErr(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%w: %w", ErrQueryCanceled, err)
+	default:
+		return err
 	}
 }
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,20 +17,62 @@ import (
 )
 
 type Movie struct {
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"-"`
-	Title     string    `json:"title"`
-	Year      int32     `json:"year,omitempty"`
-	Runtime   string    `json:"runtime,omitempty"`
-	Genres    []string  `json:"genres,omitempty"`
-	Version   int32     `json:"version"`
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"-"`
+	Title     string     `json:"title"`
+	Year      int32      `json:"year,omitempty"`
+	Runtime   Runtime    `json:"runtime,omitempty"`
+	Genres    []string   `json:"genres,omitempty"`
+	Version   int32      `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Highlight string     `json:"highlight,omitempty"`
+	Credits   []*Credit  `json:"credits,omitempty"`
+
+	// AverageRating and RatingCount are maintained by the rating model and
+	// are not covered by Version.
+	AverageRating float64 `json:"average_rating,omitempty"`
+	RatingCount   int32   `json:"rating_count"`
+}
+
+const exportBatchSize = 500
+
+var (
+	searchWordRX      = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	websearchSyntaxRX = regexp.MustCompile(`(?i)"|(?:^|\s)-|\sor\s`)
+)
+
+const (
+	MatchAll = "all"
+	MatchAny = "any"
+)
+
+// MovieFilter selects movies for listing and export. Zero-valued fields are
+// ignored; the remaining conditions must all hold, or with Match set to
+// MatchAny, at least one of them.
+type MovieFilter struct {
+	Title          string
+	SearchLanguage string
+	Genres         []string
+	GenresAny      []string
+	GenresNone     []string
+	YearFrom       int
+	YearTo         int
+	RuntimeMin     Runtime
+	RuntimeMax     Runtime
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	PersonID       int64
+	Match          string
 }
 
 type MovieModel struct {
-	DB *sql.DB
+	DB       dbtx
+	Timeouts Timeouts
 }
 
-func ValidateMovie(v *validator.Validator, movie *Movie) {
+// ValidateMovie checks movie and rewrites its genres to the slugs they name or
+// are aliases of in vocabulary.
+func ValidateMovie(v *validator.Validator, movie *Movie, vocabulary GenreVocabulary) {
 	v.Check(movie.Title != "", "title", "must be provided")
 	v.Check(len(movie.Title) <= 500, "title", "must not be more than 500 bytes long")
 
@@ -35,40 +80,95 @@ func ValidateMovie(v *validator.Validator, movie *Movie) {
 	v.Check(movie.Year >= 1888, "year", "must be greater than 1888")
 	v.Check(movie.Year <= int32(time.Now().Year()), "year", "must not be in the future")
 
-	v.Check(strings.TrimSpace(movie.Runtime) != "", "runtime", "must be provided")
+	v.Check(movie.Runtime != 0, "runtime", "must be provided")
+	v.Check(movie.Runtime > 0, "runtime", "must be a positive integer")
 
 	v.Check(movie.Genres != nil, "genres", "must be provided")
 	v.Check(len(movie.Genres) >= 1, "genres", "must contain at least 1 genre")
 	v.Check(len(movie.Genres) <= 5, "genres", "must not contain more than 5 genres")
+
+	for i, genre := range movie.Genres {
+		slug, ok := vocabulary.Normalize(genre)
+		if !ok {
+			v.AddError("genres", fmt.Sprintf("contains unknown genre %q", genre))
+			continue
+		}
+		movie.Genres[i] = slug
+	}
+
 	v.Check(validator.Unique(movie.Genres), "genres", "must not contain duplicate values")
 }
 
-func (m MovieModel) Insert(movie *Movie) error {
+// ValidateMovieFilter checks f and rewrites its genre filters to the slugs
+// they name or are aliases of in vocabulary, as ValidateMovie does for the
+// stored genres.
+func ValidateMovieFilter(v *validator.Validator, f *MovieFilter, vocabulary GenreVocabulary) {
+	v.Check(f.YearFrom == 0 || f.YearFrom >= 1888, "year_from", "must be greater than 1888")
+	v.Check(f.YearFrom <= time.Now().Year(), "year_from", "must not be in the future")
+	v.Check(f.YearTo == 0 || f.YearTo >= 1888, "year_to", "must be greater than 1888")
+	v.Check(f.YearTo == 0 || f.YearTo >= f.YearFrom, "year_to", "must not be less than year_from")
+
+	v.Check(f.RuntimeMax == 0 || f.RuntimeMax >= f.RuntimeMin, "runtime_max", "must not be less than runtime_min")
+
+	v.Check(f.CreatedBefore.IsZero() || f.CreatedBefore.After(f.CreatedAfter), "created_before", "must be later than created_after")
+
+	v.Check(len(f.GenresAny) <= 20, "genres_any", "must not contain more than 20 genres")
+	v.Check(len(f.GenresNone) <= 20, "genres_none", "must not contain more than 20 genres")
+
+	normalizeGenreFilter(v, "genres", f.Genres, vocabulary)
+	normalizeGenreFilter(v, "genres_any", f.GenresAny, vocabulary)
+	normalizeGenreFilter(v, "genres_none", f.GenresNone, vocabulary)
+
+	v.Check(f.PersonID >= 0, "person_id", "must be a positive integer")
+
+	v.Check(validator.PermittedValue(f.Match, MatchAll, MatchAny), "match", "must be all or any")
+}
+
+func normalizeGenreFilter(v *validator.Validator, key string, genres []string, vocabulary GenreVocabulary) {
+	for i, genre := range genres {
+		slug, ok := vocabulary.Normalize(genre)
+		if !ok {
+			v.AddError(key, fmt.Sprintf("contains unknown genre %q", genre))
+			continue
+		}
+		genres[i] = slug
+	}
+}
+
+func (m MovieModel) Insert(ctx context.Context, movie *Movie) error {
 	query := `
         INSERT INTO movies (title, year, runtime, genres)
         VALUES($1, $2, $3, $4)
         RETURNING id, created_at, version`
 	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres)}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		err := lockGenres(ctx, db, movie.Genres)
+		if err != nil {
+			return err
+		}
+
+		err = db.QueryRowContext(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+		return contextErr(ctx, err)
+	})
 }
 
-func (m MovieModel) Get(id int64) (*Movie, error) {
+func (m MovieModel) Get(ctx context.Context, id int64) (*Movie, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
 	query := `
-        SELECT id, created_at, title, year, runtime, genres, version
+        SELECT id, created_at, title, year, runtime, genres, version, average_rating, rating_count
         FROM movies
-        WHERE id = $1`
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	var movie Movie
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, query, id).Scan(
@@ -78,24 +178,26 @@ func (m MovieModel) Get(id int64) (*Movie, error) {
 		&movie.Year,
 		&movie.Runtime,
 		pq.Array(&movie.Genres),
-		&movie.Version)
+		&movie.Version,
+		&movie.AverageRating,
+		&movie.RatingCount)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrRecordNotFound
 		default:
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 	}
 
 	return &movie, nil
 }
 
-func (m MovieModel) Update(movie *Movie) error {
+func (m MovieModel) Update(ctx context.Context, movie *Movie) error {
 	query := `
         UPDATE movies
         SET title = $1, year = $2, runtime = $3, genres = $4, version = version + 1
-        WHERE id = $5 AND version = $6
+        WHERE id = $5 AND version = $6 AND deleted_at IS NULL
         RETURNING version`
 
 	args := []any{
@@ -107,37 +209,105 @@ func (m MovieModel) Update(movie *Movie) error {
 		movie.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		err := lockGenres(ctx, db, movie.Genres)
+		if err != nil {
+			return err
+		}
+
+		err = db.QueryRowContext(ctx, query, args...).Scan(&movie.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m MovieModel) Delete(ctx context.Context, movie *Movie) error {
+	query := `
+        UPDATE movies
+        SET deleted_at = NOW(), version = version + 1
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+        RETURNING version, deleted_at`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&movie.Version)
+	err := m.DB.QueryRowContext(ctx, query, movie.ID, movie.Version).Scan(&movie.Version, &movie.DeletedAt)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return contextErr(ctx, err)
 		}
 	}
 
 	return nil
 }
 
-func (m MovieModel) Delete(id int64) error {
+func (m MovieModel) Restore(ctx context.Context, id int64) (*Movie, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        UPDATE movies
+        SET deleted_at = NULL, version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING id, created_at, title, year, runtime, genres, version, average_rating, rating_count`
+
+	var movie Movie
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&movie.ID,
+		&movie.CreatedAt,
+		&movie.Title,
+		&movie.Year,
+		&movie.Runtime,
+		pq.Array(&movie.Genres),
+		&movie.Version,
+		&movie.AverageRating,
+		&movie.RatingCount)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &movie, nil
+}
+
+func (m MovieModel) Purge(ctx context.Context, id int64) error {
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
 	query := `
         DELETE FROM movies
-        WHERE id = $1`
+        WHERE id = $1 AND deleted_at IS NOT NULL`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return nil
+		return contextErr(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -152,51 +322,352 @@ func (m MovieModel) Delete(id int64) error {
 	return nil
 }
 
-func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]*Movie, Metadata, error) {
-	query := fmt.Sprintf(`
-        SELECT COUNT(*) OVER(), id, created_at, title, year, runtime, genres, version
-        FROM movies
-        WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-        AND (genres @> $2 OR $2 = '{}')
-        ORDER BY %s %s, id ASC
-        LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+func (m MovieModel) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	query := `
+        DELETE FROM movies
+        WHERE deleted_at < $1
+        RETURNING id`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
-	args := []any{title, pq.Array(genres), filters.limit(), filters.offset()}
-	rows, err := m.DB.QueryContext(ctx, query, args...)
+	rows, err := m.DB.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return ids, nil
+}
+
+func (m MovieModel) GetAll(ctx context.Context, filter MovieFilter, filters Filters) ([]*Movie, Metadata, error) {
+	return m.list(ctx, filter.query(), filters)
+}
+
+func (m MovieModel) GetAllDeleted(ctx context.Context, filters Filters) ([]*Movie, Metadata, error) {
+	return m.list(ctx, movieQuery{where: "deleted_at IS NOT NULL"}, filters)
+}
+
+func (m MovieModel) list(ctx context.Context, q movieQuery, filters Filters) ([]*Movie, Metadata, error) {
+	where, args := q.where, q.args
+
+	c, err := filters.cursor()
 	if err != nil {
 		return nil, Metadata{}, err
 	}
 
-	defer rows.Close()
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
 
 	totalRecords := 0
+	totalColumn := "0"
+
+	switch {
+	case c == nil && filters.IncludeTotal:
+		totalColumn = "COUNT(*) OVER()"
+	case c != nil && filters.IncludeTotal:
+		err := m.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM movies WHERE "+where, args...).Scan(&totalRecords)
+		if err != nil {
+			return nil, Metadata{}, contextErr(ctx, err)
+		}
+	}
+
+	offset := filters.offset()
+	if c != nil {
+		where += " AND " + filters.keysetCondition(c, len(args)+1, len(args)+2)
+		args = append(args, c.Value, c.ID)
+		offset = 0
+	}
+	args = append(args, filters.limit()+1, offset)
+
+	rank, headline := "0", "''"
+	if q.rank != "" {
+		rank, headline = q.rank, q.headline
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s, id, created_at, title, year, runtime, genres, version, average_rating, rating_count, deleted_at, %s AS relevance, %s
+        FROM movies
+        WHERE %s
+        ORDER BY %s
+        LIMIT $%d OFFSET $%d`, totalColumn, rank, headline, where, filters.orderBy(c), len(args)-1, len(args))
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+
+	defer rows.Close()
+
 	movies := []*Movie{}
 	for rows.Next() {
-		var movie Movie
+		var (
+			movie       Movie
+			windowTotal int
+			relevance   float32
+		)
 		err := rows.Scan(
-			&totalRecords,
+			&windowTotal,
 			&movie.ID,
 			&movie.CreatedAt,
 			&movie.Title,
 			&movie.Year,
 			&movie.Runtime,
 			pq.Array(&movie.Genres),
-			&movie.Version)
+			&movie.Version,
+			&movie.AverageRating,
+			&movie.RatingCount,
+			&movie.DeletedAt,
+			&relevance,
+			&movie.Highlight)
 		if err != nil {
-			return nil, Metadata{}, err
+			return nil, Metadata{}, contextErr(ctx, err)
 		}
 
+		if c == nil {
+			totalRecords = windowTotal
+		}
 		movies = append(movies, &movie)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, Metadata{}, err
+		return nil, Metadata{}, contextErr(ctx, err)
 	}
 
-	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	movies, metadata := paginateMovies(filters, c, totalRecords, movies)
 
 	return movies, metadata, nil
 }
+
+// Export calls fn for every movie matching filter in id order. Rows are read
+// through a server-side cursor in batches so memory use does not grow with the
+// size of the catalogue.
+func (m MovieModel) Export(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error {
+	db := m.DB
+	if beginner, ok := m.DB.(txBeginner); ok {
+		tx, err := beginner.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		defer tx.Rollback()
+		db = tx
+	}
+
+	q := filter.query()
+	query := `
+        DECLARE movies_export NO SCROLL CURSOR FOR
+        SELECT id, created_at, title, year, runtime, genres, version, average_rating, rating_count
+        FROM movies
+        WHERE ` + q.where + `
+        ORDER BY id`
+
+	_, err := db.ExecContext(ctx, query, q.args...)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+	defer db.ExecContext(context.Background(), "CLOSE movies_export")
+
+	for {
+		movies, err := m.fetchExport(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		for _, movie := range movies {
+			err := fn(movie)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(movies) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func (m MovieModel) fetchExport(ctx context.Context, db dbtx) ([]*Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM movies_export", exportBatchSize))
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	movies := make([]*Movie, 0, exportBatchSize)
+	for rows.Next() {
+		var movie Movie
+		err := rows.Scan(
+			&movie.ID,
+			&movie.CreatedAt,
+			&movie.Title,
+			&movie.Year,
+			&movie.Runtime,
+			pq.Array(&movie.Genres),
+			&movie.Version,
+			&movie.AverageRating,
+			&movie.RatingCount)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		movies = append(movies, &movie)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return movies, nil
+}
+
+// movieQuery is the SQL form of a MovieFilter. When the filter searches by
+// title, rank and headline hold expressions scoring and highlighting matches.
+type movieQuery struct {
+	where    string
+	args     []any
+	rank     string
+	headline string
+}
+
+func (f MovieFilter) query() movieQuery {
+	var (
+		c conditions
+		q movieQuery
+	)
+
+	if f.Title != "" {
+		language := c.param(f.searchLanguage())
+		document := fmt.Sprintf("to_tsvector($%d::regconfig, title)", language)
+		tsquery := fmt.Sprintf("websearch_to_tsquery($%d::regconfig, $%d)", language, c.param(f.Title))
+		if prefix := prefixQuery(f.Title); prefix != "" {
+			tsquery = fmt.Sprintf("(%s || to_tsquery($%d::regconfig, $%d))", tsquery, language, c.param(prefix))
+		}
+
+		c.clauses = append(c.clauses, document+" @@ "+tsquery)
+		q.rank = fmt.Sprintf("ts_rank(%s, %s)", document, tsquery)
+		q.headline = fmt.Sprintf("ts_headline($%d::regconfig, title, %s, 'HighlightAll=true')", language, tsquery)
+	}
+	if len(f.Genres) > 0 {
+		c.add("genres @> $%d", pq.Array(f.Genres))
+	}
+	if len(f.GenresAny) > 0 {
+		c.add("genres && $%d", pq.Array(f.GenresAny))
+	}
+	if len(f.GenresNone) > 0 {
+		c.add("NOT genres && $%d", pq.Array(f.GenresNone))
+	}
+	if f.YearFrom != 0 {
+		c.add("year >= $%d", f.YearFrom)
+	}
+	if f.YearTo != 0 {
+		c.add("year <= $%d", f.YearTo)
+	}
+	if f.RuntimeMin != 0 {
+		c.add("runtime >= $%d", f.RuntimeMin)
+	}
+	if f.RuntimeMax != 0 {
+		c.add("runtime <= $%d", f.RuntimeMax)
+	}
+	if !f.CreatedAfter.IsZero() {
+		c.add("created_at > $%d", f.CreatedAfter)
+	}
+	if !f.CreatedBefore.IsZero() {
+		c.add("created_at < $%d", f.CreatedBefore)
+	}
+	if f.PersonID != 0 {
+		c.add("EXISTS (SELECT 1 FROM movie_credits WHERE movie_credits.movie_id = movies.id AND movie_credits.person_id = $%d)", f.PersonID)
+	}
+
+	q.where, q.args = "deleted_at IS NULL", c.args
+	if len(c.clauses) > 0 {
+		q.where += " AND " + c.join(f.Match == MatchAny)
+	}
+
+	return q
+}
+
+func (f MovieFilter) searchLanguage() string {
+	if f.SearchLanguage == "" {
+		return "simple"
+	}
+	return f.SearchLanguage
+}
+
+// prefixQuery turns a plain search such as "godf" into the tsquery "godf:*"
+// so that partially typed words match. Searches using websearch syntax are
+// left to websearch_to_tsquery alone, since OR-ing in a prefix query would
+// defeat quoted phrases and exclusions.
+func prefixQuery(search string) string {
+	if websearchSyntaxRX.MatchString(search) {
+		return ""
+	}
+
+	words := searchWordRX.FindAllString(search, -1)
+	for i := range words {
+		words[i] += ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// paginateMovies trims the extra row fetched to detect a following page,
+// restores the requested order for backward cursors and builds the metadata.
+func paginateMovies(filters Filters, c *cursor, totalRecords int, movies []*Movie) ([]*Movie, Metadata) {
+	hasMore := len(movies) > filters.limit()
+	if hasMore {
+		movies = movies[:filters.limit()]
+	}
+
+	if c != nil && c.Backward {
+		slices.Reverse(movies)
+	}
+
+	var first, last cursor
+	if len(movies) > 0 && filters.keyset() {
+		first = movies[0].cursor(filters.sortColumn())
+		last = movies[len(movies)-1].cursor(filters.sortColumn())
+	}
+
+	return movies, filters.pageMetadata(c, totalRecords, len(movies), hasMore, first, last)
+}
+
+func (movie *Movie) cursor(column string) cursor {
+	var value string
+
+	switch column {
+	case "id":
+		value = strconv.FormatInt(movie.ID, 10)
+	case "title":
+		value = movie.Title
+	case "year":
+		value = strconv.Itoa(int(movie.Year))
+	case "runtime":
+		value = strconv.Itoa(int(movie.Runtime))
+	case "average_rating":
+		value = strconv.FormatFloat(movie.AverageRating, 'f', 2, 64)
+	case "deleted_at":
+		if movie.DeletedAt != nil {
+			value = movie.DeletedAt.Format(time.RFC3339)
+		}
+	default:
+		panic("unsupported sort column: " + column)
+	}
+
+	return cursor{Value: value, ID: movie.ID}
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"greenlight/internal/validator"
+)
+
+type Person struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"-"`
+	Name      string    `json:"name"`
+	BirthYear int32     `json:"birth_year,omitempty"`
+	Version   int32     `json:"version"`
+}
+
+type PersonModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func ValidatePerson(v *validator.Validator, person *Person) {
+	v.Check(person.Name != "", "name", "must be provided")
+	v.Check(len(person.Name) <= 500, "name", "must not be more than 500 bytes long")
+
+	v.Check(person.BirthYear == 0 || person.BirthYear >= 1800, "birth_year", "must be greater than 1800")
+	v.Check(person.BirthYear <= int32(time.Now().Year()), "birth_year", "must not be in the future")
+}
+
+func (m PersonModel) Insert(ctx context.Context, person *Person) error {
+	query := `
+        INSERT INTO people (name, birth_year)
+        VALUES ($1, NULLIF($2, 0))
+        RETURNING id, created_at, version`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, person.Name, person.BirthYear).Scan(&person.ID, &person.CreatedAt, &person.Version)
+	return contextErr(ctx, err)
+}
+
+func (m PersonModel) Get(ctx context.Context, id int64) (*Person, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT id, created_at, name, coalesce(birth_year, 0), version
+        FROM people
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var person Person
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&person.ID,
+		&person.CreatedAt,
+		&person.Name,
+		&person.BirthYear,
+		&person.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &person, nil
+}
+
+// GetAll returns a page of people whose name contains name, ignoring case.
+func (m PersonModel) GetAll(ctx context.Context, name string, filters Filters) ([]*Person, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT COUNT(*) OVER(), id, created_at, name, coalesce(birth_year, 0), version
+        FROM people
+        WHERE $1 = '' OR name ILIKE '%%' || $1 || '%%'
+        ORDER BY %s %s, id ASC
+        LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, likeEscaper.Replace(name), filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	people := []*Person{}
+	for rows.Next() {
+		var person Person
+		err := rows.Scan(
+			&totalRecords,
+			&person.ID,
+			&person.CreatedAt,
+			&person.Name,
+			&person.BirthYear,
+			&person.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, contextErr(ctx, err)
+		}
+		people = append(people, &person)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return people, metadata, nil
+}
+
+func (m PersonModel) Update(ctx context.Context, person *Person) error {
+	query := `
+        UPDATE people
+        SET name = $1, birth_year = NULLIF($2, 0), version = version + 1
+        WHERE id = $3 AND version = $4
+        RETURNING version`
+	args := []any{person.Name, person.BirthYear, person.ID, person.Version}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&person.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a person together with all of their credits.
+func (m PersonModel) Delete(ctx context.Context, id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+        DELETE FROM people
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -2,9 +2,7 @@ package data
 
 import (
 	"context"
-	"database/sql"
 	"slices"
-	"time"
 
 	"github.com/lib/pq"
 )
@@ -12,15 +10,16 @@ import (
 type Permissions []string
 
 type PermissionModel struct {
-	DB *sql.DB
+	DB       dbtx
+	Timeouts Timeouts
 }
 
 func (p Permissions) Include(code string) bool {
 	return slices.Contains(p, code)
 }
 
-func (m PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m PermissionModel) GetAllForUser(ctx context.Context, userID int64) (Permissions, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
 	defer cancel()
 
 	query := `
@@ -33,7 +32,7 @@ func (m PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 	rows, err := m.DB.QueryContext(ctx, query, userID)
 
 	if err != nil {
-		return nil, err
+		return nil, contextErr(ctx, err)
 	}
 	defer rows.Close()
 
@@ -42,19 +41,19 @@ func (m PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 		var permission string
 		err := rows.Scan(&permission)
 		if err != nil {
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 		permissions = append(permissions, permission)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, contextErr(ctx, err)
 	}
 
 	return permissions, nil
 }
 
-func (m PermissionModel) AddForUser(userID int64, codes ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m PermissionModel) AddForUser(ctx context.Context, userID int64, codes ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	query := `
@@ -62,5 +61,5 @@ func (m PermissionModel) AddForUser(userID int64, codes ...string) error {
         SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)`
 
 	_, err := m.DB.ExecContext(ctx, query, userID, pq.Array(codes))
-	return err
+	return contextErr(ctx, err)
 }
@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"greenlight/internal/validator"
+)
+
+var ErrDuplicateRating = errors.New("duplicate rating")
+
+type Rating struct {
+	MovieID   int64     `json:"movie_id"`
+	UserID    int64     `json:"user_id"`
+	Score     int32     `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RatingModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func ValidateRating(v *validator.Validator, rating *Rating) {
+	v.Check(rating.Score != 0, "score", "must be provided")
+	v.Check(rating.Score >= 1 && rating.Score <= 10, "score", "must be between 1 and 10")
+}
+
+func (m RatingModel) Get(ctx context.Context, movieID, userID int64) (*Rating, error) {
+	query := `
+        SELECT movie_id, user_id, score, created_at, updated_at
+        FROM ratings
+        WHERE movie_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var rating Rating
+	err := m.DB.QueryRowContext(ctx, query, movieID, userID).Scan(
+		&rating.MovieID,
+		&rating.UserID,
+		&rating.Score,
+		&rating.CreatedAt,
+		&rating.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &rating, nil
+}
+
+// Insert records a new rating, returning ErrDuplicateRating if the user has
+// already rated the movie.
+func (m RatingModel) Insert(ctx context.Context, rating *Rating) error {
+	query := `
+        INSERT INTO ratings (movie_id, user_id, score)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (movie_id, user_id) DO NOTHING
+        RETURNING created_at, updated_at`
+
+	return m.update(ctx, rating.MovieID, func(ctx context.Context, db dbtx) error {
+		err := db.QueryRowContext(ctx, query, rating.MovieID, rating.UserID, rating.Score).Scan(&rating.CreatedAt, &rating.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicateRating
+		}
+		return err
+	})
+}
+
+// Set records a rating or replaces the score of an existing one.
+func (m RatingModel) Set(ctx context.Context, rating *Rating) error {
+	query := `
+        INSERT INTO ratings (movie_id, user_id, score)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (movie_id, user_id) DO UPDATE
+        SET score = EXCLUDED.score, updated_at = NOW()
+        RETURNING created_at, updated_at`
+
+	return m.update(ctx, rating.MovieID, func(ctx context.Context, db dbtx) error {
+		return db.QueryRowContext(ctx, query, rating.MovieID, rating.UserID, rating.Score).Scan(&rating.CreatedAt, &rating.UpdatedAt)
+	})
+}
+
+func (m RatingModel) Delete(ctx context.Context, movieID, userID int64) error {
+	query := `
+        DELETE FROM ratings
+        WHERE movie_id = $1 AND user_id = $2`
+
+	return m.update(ctx, movieID, func(ctx context.Context, db dbtx) error {
+		result, err := db.ExecContext(ctx, query, movieID, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// update runs fn and recalculates the rating aggregates of the movie in one
+// transaction. The movie row is locked first, so the recalculation always
+// sees the ratings committed by concurrent updates. It returns
+// ErrRecordNotFound if the movie does not exist or is in the trash.
+func (m RatingModel) update(ctx context.Context, movieID int64, fn func(ctx context.Context, db dbtx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		var id int64
+		err := db.QueryRowContext(ctx, "SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", movieID).Scan(&id)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		err = fn(ctx, db)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		query := `
+            UPDATE movies
+            SET average_rating = aggregate.average, rating_count = aggregate.count
+            FROM (
+                SELECT coalesce(round(avg(score), 2), 0) AS average, count(*) AS count
+                FROM ratings
+                WHERE movie_id = $1
+            ) AS aggregate
+            WHERE id = $1`
+
+		_, err = db.ExecContext(ctx, query, movieID)
+		return contextErr(ctx, err)
+	})
+}
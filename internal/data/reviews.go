@@ -0,0 +1,197 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"greenlight/internal/validator"
+)
+
+var ErrDuplicateReview = errors.New("duplicate review")
+
+type Review struct {
+	ID        int64     `json:"id"`
+	MovieID   int64     `json:"movie_id"`
+	UserID    int64     `json:"user_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Hidden    bool      `json:"hidden,omitempty"`
+	Version   int32     `json:"version"`
+}
+
+type ReviewModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func ValidateReview(v *validator.Validator, review *Review) {
+	v.Check(review.Body != "", "body", "must be provided")
+	v.Check(len(review.Body) <= 10_000, "body", "must not be more than 10000 bytes long")
+}
+
+func (m ReviewModel) Insert(ctx context.Context, review *Review) error {
+	query := `
+        INSERT INTO reviews (movie_id, user_id, body)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at, updated_at, version, (SELECT name FROM users WHERE id = $2)`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, review.MovieID, review.UserID, review.Body).Scan(
+		&review.ID,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.Version,
+		&review.Author,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "reviews_movie_id_user_id_key"`:
+			return ErrDuplicateReview
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m ReviewModel) Get(ctx context.Context, movieID, id int64) (*Review, error) {
+	query := `
+        SELECT reviews.id, movie_id, user_id, users.name, body, reviews.created_at, updated_at, hidden, reviews.version
+        FROM reviews
+        INNER JOIN users ON users.id = reviews.user_id
+        WHERE reviews.id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	var review Review
+	err := m.DB.QueryRowContext(ctx, query, id, movieID).Scan(
+		&review.ID,
+		&review.MovieID,
+		&review.UserID,
+		&review.Author,
+		&review.Body,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.Hidden,
+		&review.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &review, nil
+}
+
+// GetAllForMovie returns a page of the reviews of a movie. Hidden reviews are
+// left out unless includeHidden is set.
+func (m ReviewModel) GetAllForMovie(ctx context.Context, movieID int64, includeHidden bool, filters Filters) ([]*Review, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT COUNT(*) OVER(), reviews.id, movie_id, user_id, users.name, body, reviews.created_at, updated_at, hidden, reviews.version
+        FROM reviews
+        INNER JOIN users ON users.id = reviews.user_id
+        WHERE movie_id = $1 AND ($2 OR NOT hidden)
+        ORDER BY reviews.%s %s, reviews.id ASC
+        LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID, includeHidden, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reviews := []*Review{}
+	for rows.Next() {
+		var review Review
+		err := rows.Scan(
+			&totalRecords,
+			&review.ID,
+			&review.MovieID,
+			&review.UserID,
+			&review.Author,
+			&review.Body,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+			&review.Hidden,
+			&review.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, contextErr(ctx, err)
+		}
+		reviews = append(reviews, &review)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return reviews, metadata, nil
+}
+
+// Update saves the body and hidden flag of review.
+func (m ReviewModel) Update(ctx context.Context, review *Review) error {
+	query := `
+        UPDATE reviews
+        SET body = $1, hidden = $2, updated_at = CASE WHEN body = $1 THEN updated_at ELSE NOW() END, version = version + 1
+        WHERE id = $3 AND movie_id = $4 AND version = $5
+        RETURNING updated_at, version`
+	args := []any{review.Body, review.Hidden, review.ID, review.MovieID, review.Version}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&review.UpdatedAt, &review.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return contextErr(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m ReviewModel) Delete(ctx context.Context, movieID, id int64) error {
+	query := `
+        DELETE FROM reviews
+        WHERE id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, movieID)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+)
+
+type MovieRevision struct {
+	MovieID   int64     `json:"movie_id"`
+	Version   int32     `json:"version"`
+	Action    string    `json:"action"`
+	UserID    *int64    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Title     string    `json:"title"`
+	Year      int32     `json:"year"`
+	Runtime   Runtime   `json:"runtime"`
+	Genres    []string  `json:"genres"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type MovieRevisionModel struct {
+	DB       dbtx
+	Timeouts Timeouts
+}
+
+func NewMovieRevision(movie *Movie, action string, userID int64) *MovieRevision {
+	return &MovieRevision{
+		MovieID: movie.ID,
+		Version: movie.Version,
+		Action:  action,
+		UserID:  &userID,
+		Title:   movie.Title,
+		Year:    movie.Year,
+		Runtime: movie.Runtime,
+		Genres:  slices.Clone(movie.Genres),
+	}
+}
+
+func DiffRevisions(from, to *MovieRevision) []FieldChange {
+	changes := []FieldChange{}
+
+	if from.Title != to.Title {
+		changes = append(changes, FieldChange{Field: "title", From: from.Title, To: to.Title})
+	}
+	if from.Year != to.Year {
+		changes = append(changes, FieldChange{Field: "year", From: from.Year, To: to.Year})
+	}
+	if from.Runtime != to.Runtime {
+		changes = append(changes, FieldChange{Field: "runtime", From: from.Runtime, To: to.Runtime})
+	}
+	if !slices.Equal(from.Genres, to.Genres) {
+		changes = append(changes, FieldChange{Field: "genres", From: from.Genres, To: to.Genres})
+	}
+
+	return changes
+}
+
+func (m MovieRevisionModel) Insert(ctx context.Context, revision *MovieRevision) error {
+	query := `
+        INSERT INTO movie_revisions (movie_id, version, action, user_id, title, year, runtime, genres)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at`
+
+	args := []any{
+		revision.MovieID,
+		revision.Version,
+		revision.Action,
+		revision.UserID,
+		revision.Title,
+		revision.Year,
+		revision.Runtime,
+		pq.Array(revision.Genres),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&revision.CreatedAt)
+	return contextErr(ctx, err)
+}
+
+func (m MovieRevisionModel) Get(ctx context.Context, movieID int64, version int32) (*MovieRevision, error) {
+	query := `
+        SELECT movie_id, version, action, user_id, created_at, title, year, runtime, genres
+        FROM movie_revisions
+        WHERE movie_id = $1 AND version = $2`
+
+	var revision MovieRevision
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, movieID, version).Scan(
+		&revision.MovieID,
+		&revision.Version,
+		&revision.Action,
+		&revision.UserID,
+		&revision.CreatedAt,
+		&revision.Title,
+		&revision.Year,
+		&revision.Runtime,
+		pq.Array(&revision.Genres))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &revision, nil
+}
+
+func (m MovieRevisionModel) GetAllForMovie(ctx context.Context, movieID int64, filters Filters) ([]*MovieRevision, Metadata, error) {
+	query := `
+        SELECT COUNT(*) OVER(), movie_id, version, action, user_id, created_at, title, year, runtime, genres
+        FROM movie_revisions
+        WHERE movie_id = $1
+        ORDER BY version ` + filters.sortDirection() + `
+        LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	revisions := []*MovieRevision{}
+	for rows.Next() {
+		var revision MovieRevision
+		err := rows.Scan(
+			&totalRecords,
+			&revision.MovieID,
+			&revision.Version,
+			&revision.Action,
+			&revision.UserID,
+			&revision.CreatedAt,
+			&revision.Title,
+			&revision.Year,
+			&revision.Runtime,
+			pq.Array(&revision.Genres))
+		if err != nil {
+			return nil, Metadata{}, contextErr(ctx, err)
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, contextErr(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return revisions, metadata, nil
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRuntimeFormat = errors.New("invalid runtime format")
+
+var (
+	runtimeMinutesRX = regexp.MustCompile(`^(\d+)\s*(?:m|min|mins|minute|minutes)?$`)
+	runtimeHoursRX   = regexp.MustCompile(`^(\d+)\s*(?:h|hr|hrs|hour|hours)\s*(?:(\d+)\s*(?:m|min|mins|minute|minutes)?)?$`)
+	runtimeClockRX   = regexp.MustCompile(`^(\d+):([0-5]\d)$`)
+	runtimeISORX     = regexp.MustCompile(`^pt(?:(\d+)h)?(?:(\d+)m)?$`)
+)
+
+// Runtime is a movie running time in whole minutes.
+type Runtime int32
+
+// ParseRuntime accepts "102", "102 mins", "1h42", "1h 42m", "1 hr 42 min",
+// "1:42" and ISO 8601 durations such as "PT1H42M".
+func ParseRuntime(s string) (Runtime, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var hours, minutes string
+	if m := runtimeMinutesRX.FindStringSubmatch(s); m != nil {
+		minutes = m[1]
+	} else if m := runtimeHoursRX.FindStringSubmatch(s); m != nil {
+		hours, minutes = m[1], m[2]
+	} else if m := runtimeClockRX.FindStringSubmatch(s); m != nil {
+		hours, minutes = m[1], m[2]
+	} else if m := runtimeISORX.FindStringSubmatch(s); m != nil && s != "pt" {
+		hours, minutes = m[1], m[2]
+	} else {
+		return 0, ErrInvalidRuntimeFormat
+	}
+
+	h, err := parseRuntimePart(hours)
+	if err != nil {
+		return 0, err
+	}
+	mins, err := parseRuntimePart(minutes)
+	if err != nil {
+		return 0, err
+	}
+	if hours != "" && mins >= 60 {
+		return 0, ErrInvalidRuntimeFormat
+	}
+
+	total := h*60 + mins
+	if total > 1<<31-1 {
+		return 0, ErrInvalidRuntimeFormat
+	}
+
+	return Runtime(total), nil
+}
+
+func parseRuntimePart(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidRuntimeFormat
+	}
+
+	return i, nil
+}
+
+func (r Runtime) String() string {
+	return fmt.Sprintf("%d mins", r)
+}
+
+func (r Runtime) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(r.String())), nil
+}
+
+func (r *Runtime) UnmarshalJSON(jsonValue []byte) error {
+	if string(jsonValue) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(jsonValue, &s); err != nil {
+		var i int32
+		if err := json.Unmarshal(jsonValue, &i); err != nil {
+			return ErrInvalidRuntimeFormat
+		}
+		*r = Runtime(i)
+		return nil
+	}
+
+	parsed, err := ParseRuntime(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
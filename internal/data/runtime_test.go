@@ -0,0 +1,51 @@
+package data
+
+import "testing"
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Runtime
+		wantErr bool
+	}{
+		{input: "102", want: 102},
+		{input: "102 mins", want: 102},
+		{input: "102min", want: 102},
+		{input: " 102 Minutes ", want: 102},
+		{input: "1h42", want: 102},
+		{input: "1h 42m", want: 102},
+		{input: "1 hr 42 min", want: 102},
+		{input: "2 hours", want: 120},
+		{input: "1:42", want: 102},
+		{input: "PT1H42M", want: 102},
+		{input: "pt90m", want: 90},
+		{input: "PT2H", want: 120},
+		{input: "0", want: 0},
+		{input: "", wantErr: true},
+		{input: "PT", wantErr: true},
+		{input: "1h60", wantErr: true},
+		{input: "1:60", wantErr: true},
+		{input: "-5 mins", wantErr: true},
+		{input: "1.5 hours", wantErr: true},
+		{input: "102 seconds", wantErr: true},
+		{input: "99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRuntime(tt.input)
+			if tt.wantErr {
+				if err != ErrInvalidRuntimeFormat {
+					t.Errorf("got %v, %v; want %v", got, err, ErrInvalidRuntimeFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d; want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+const maxUserAgentLength = 512
+
+// Session describes a signed-in client without revealing its tokens. ID is
+// stable for the life of the session and safe to show to its owner.
+//
+// A session is a family of tokens: the refresh token issued at login, whose
+// id is the family id, and the access and refresh tokens rotated from it.
+// Every token of a family expires with the first refresh token, so sessions
+// have an absolute lifetime however often they are refreshed.
+type Session struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Expiry     time.Time  `json:"expiry"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+func generateSession(userID int64, ttl time.Duration, scope, ip, userAgent string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	token.IP = ip
+	token.UserAgent = userAgent
+	if len(token.UserAgent) > maxUserAgentLength {
+		token.UserAgent = strings.ToValidUTF8(token.UserAgent[:maxUserAgentLength], "")
+	}
+
+	return token, nil
+}
+
+// generateSessionPair returns an access and a refresh token for the client,
+// the access token expiring no later than the refresh token.
+func generateSessionPair(userID int64, accessTTL, refreshTTL time.Duration, ip, userAgent string) (*Token, *Token, error) {
+	access, err := generateSession(userID, min(accessTTL, refreshTTL), ScopeAuthentication, ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh, err := generateSession(userID, refreshTTL, ScopeRefresh, ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+// NewSession starts a session for the client, returning its access and
+// refresh tokens.
+func (m TokenModel) NewSession(ctx context.Context, userID int64, accessTTL, refreshTTL time.Duration, ip, userAgent string) (*Token, *Token, error) {
+	access, refresh, err := generateSessionPair(userID, accessTTL, refreshTTL, ip, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	err = inTx(ctx, m.DB, func(db dbtx) error {
+		tokens := TokenModel{DB: db, Timeouts: m.Timeouts}
+
+		err := tokens.Insert(ctx, refresh)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "UPDATE tokens SET family_id = id WHERE id = $1", refresh.ID)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		refresh.FamilyID = refresh.ID
+		access.FamilyID = refresh.ID
+
+		return tokens.Insert(ctx, access)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+// Rotate exchanges a refresh token for a new access and refresh token in the
+// same session, replacing the session's previous access token. A refresh
+// token can only be used once: presenting a spent one means it was copied,
+// so the whole session is revoked and ErrTokenReused is returned.
+func (m TokenModel) Rotate(ctx context.Context, refreshPlaintext string, accessTTL time.Duration, ip, userAgent string) (*Token, *Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	var access, refresh *Token
+	reused := false
+
+	err := inTx(ctx, m.DB, func(db dbtx) error {
+		query := `
+            SELECT user_id, expiry, family_id, last_used_at
+            FROM tokens
+            WHERE hash = $1 AND scope = $2 AND expiry > NOW()
+            FOR UPDATE`
+
+		var spent Token
+		err := db.QueryRowContext(ctx, query, HashToken(refreshPlaintext), ScopeRefresh).Scan(
+			&spent.UserID,
+			&spent.Expiry,
+			&spent.FamilyID,
+			&spent.LastUsedAt,
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		// The revocation has to be committed, so reuse is reported once the
+		// transaction is over.
+		if spent.LastUsedAt != nil {
+			reused = true
+			_, err = db.ExecContext(ctx, "DELETE FROM tokens WHERE family_id = $1", spent.FamilyID)
+			return contextErr(ctx, err)
+		}
+
+		query = `
+            UPDATE tokens
+            SET last_used_at = NOW()
+            WHERE hash = $1`
+
+		_, err = db.ExecContext(ctx, query, HashToken(refreshPlaintext))
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		query = `
+            DELETE FROM tokens
+            WHERE family_id = $1 AND scope = $2`
+
+		_, err = db.ExecContext(ctx, query, spent.FamilyID, ScopeAuthentication)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		access, refresh, err = generateSessionPair(spent.UserID, accessTTL, time.Until(spent.Expiry), ip, userAgent)
+		if err != nil {
+			return err
+		}
+
+		refresh.Expiry = spent.Expiry
+		access.FamilyID = spent.FamilyID
+		refresh.FamilyID = spent.FamilyID
+
+		tokens := TokenModel{DB: db, Timeouts: m.Timeouts}
+
+		err = tokens.Insert(ctx, refresh)
+		if err != nil {
+			return err
+		}
+
+		return tokens.Insert(ctx, access)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reused {
+		return nil, nil, ErrTokenReused
+	}
+
+	return access, refresh, nil
+}
+
+// GetSessionsForUser returns the sessions of a user, most recently used
+// first. The session holding the token whose hash is currentHash is marked as
+// current.
+func (m TokenModel) GetSessionsForUser(ctx context.Context, userID int64, currentHash []byte) ([]*Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	query := `
+        SELECT id, created_at, last_used_at, expiry, ip, user_agent, is_current
+        FROM (
+            SELECT r.id, r.created_at, r.expiry, r.ip, r.user_agent,
+                (SELECT max(t.last_used_at) FROM tokens t WHERE t.family_id = r.id) AS last_used_at,
+                EXISTS (SELECT 1 FROM tokens t WHERE t.family_id = r.id AND t.hash = $3) AS is_current
+            FROM tokens r
+            WHERE r.user_id = $1 AND r.scope = $2 AND r.family_id = r.id AND r.expiry > NOW()
+        ) sessions
+        ORDER BY coalesce(last_used_at, created_at) DESC, id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, ScopeRefresh, currentHash)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.Expiry,
+			&session.IP,
+			&session.UserAgent,
+			&session.Current,
+		)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		sessions = append(sessions, &session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession revokes every token of a session of a user, returning
+// ErrRecordNotFound if the user has no session with that id.
+func (m TokenModel) DeleteSession(ctx context.Context, userID, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	query := `
+        DELETE FROM tokens
+        WHERE family_id = $1 AND user_id = $2`
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteSessionByHash revokes the authentication token with hash and the rest
+// of its session, returning ErrRecordNotFound if there is no such token.
+func (m TokenModel) DeleteSessionByHash(ctx context.Context, hash []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		query := `
+            SELECT family_id
+            FROM tokens
+            WHERE hash = $1 AND scope = $2
+            FOR UPDATE`
+
+		var familyID *int64
+		err := db.QueryRowContext(ctx, query, hash, ScopeAuthentication).Scan(&familyID)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return contextErr(ctx, err)
+			}
+		}
+
+		err = TokenModel{DB: db, Timeouts: m.Timeouts}.DeleteByHash(ctx, ScopeAuthentication, hash)
+		if err != nil || familyID == nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM tokens WHERE family_id = $1", *familyID)
+		return contextErr(ctx, err)
+	})
+}
+
+// Touch records that the token with hash was used. To keep authenticated
+// reads from writing on every request, the time is only updated once it is
+// older than interval.
+func (m TokenModel) Touch(ctx context.Context, hash []byte, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	query := `
+        UPDATE tokens
+        SET last_used_at = NOW()
+        WHERE hash = $1 AND (last_used_at IS NULL OR last_used_at < $2)`
+
+	_, err := m.DB.ExecContext(ctx, query, hash, time.Now().Add(-interval))
+	return contextErr(ctx, err)
+}
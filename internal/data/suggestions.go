@@ -0,0 +1,50 @@
+package data
+
+import (
+	"context"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type MovieSuggestion struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Year  int32  `json:"year"`
+}
+
+// Suggest returns up to limit movies whose title starts with q or is a close
+// trigram match for it, best matches first.
+func (m MovieModel) Suggest(ctx context.Context, q string, limit int) ([]*MovieSuggestion, error) {
+	query := `
+        SELECT id, title, year
+        FROM movies
+        WHERE deleted_at IS NULL AND (title ILIKE $2 OR $1 <% title)
+        ORDER BY title ILIKE $2 DESC, word_similarity($1, title) DESC, title, id
+        LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, q, likeEscaper.Replace(q)+"%", limit)
+	if err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	defer rows.Close()
+
+	suggestions := []*MovieSuggestion{}
+	for rows.Next() {
+		var suggestion MovieSuggestion
+		err := rows.Scan(&suggestion.ID, &suggestion.Title, &suggestion.Year)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		suggestions = append(suggestions, &suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	return suggestions, nil
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/base32"
+	"errors"
 	"greenlight/internal/validator"
 	"time"
 )
@@ -14,18 +14,31 @@ const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
 	ScopePasswordReset  = "password-reset"
+	ScopeEmailChange    = "email-change"
+	ScopeRefresh        = "refresh"
 )
 
+var ErrTokenReused = errors.New("refresh token reused")
+
 type Token struct {
 	Plaintext string    `json:"token"`
 	Hash      []byte    `json:"-"`
 	UserID    int64     `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+
+	// Session metadata, only recorded for authentication and refresh tokens.
+	ID         int64      `json:"-"`
+	FamilyID   int64      `json:"-"`
+	CreatedAt  time.Time  `json:"-"`
+	LastUsedAt *time.Time `json:"-"`
+	IP         string     `json:"-"`
+	UserAgent  string     `json:"-"`
 }
 
 type TokenModel struct {
-	DB *sql.DB
+	DB       dbtx
+	Timeouts Timeouts
 }
 
 func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
@@ -42,43 +55,50 @@ func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error
 	}
 
 	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
-	hash := sha256.Sum256([]byte(token.Plaintext))
-	token.Hash = hash[:]
+	token.Hash = HashToken(token.Plaintext)
 
 	return token, nil
 }
 
+// HashToken returns the hash a token is stored under. Only hashes are kept,
+// so a leaked tokens table cannot be used to authenticate.
+func HashToken(tokenPlaintext string) []byte {
+	hash := sha256.Sum256([]byte(tokenPlaintext))
+	return hash[:]
+}
+
 func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
 	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
 }
 
-func (m TokenModel) New(userID int64, ttl time.Duration, scope string) (*Token, error) {
+func (m TokenModel) New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*Token, error) {
 	token, err := generateToken(userID, ttl, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.Insert(token)
+	err = m.Insert(ctx, token)
 	return token, err
 }
 
-func (m TokenModel) Insert(token *Token) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m TokenModel) Insert(ctx context.Context, token *Token) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	query := `
-        INSERT INTO tokens (hash, user_id, expiry, scope)
-        VALUES ($1, $2, $3, $4)`
+        INSERT INTO tokens (hash, user_id, expiry, scope, ip, user_agent, family_id)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0))
+        RETURNING id, created_at`
 
-	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope}
-	_, err := m.DB.ExecContext(ctx, query, args...)
+	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope, token.IP, token.UserAgent, token.FamilyID}
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&token.ID, &token.CreatedAt)
 
-	return err
+	return contextErr(ctx, err)
 }
 
-func (m TokenModel) DeleteAllForUser(scope string, userID int64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m TokenModel) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	query := `
@@ -86,5 +106,32 @@ func (m TokenModel) DeleteAllForUser(scope string, userID int64) error {
         WHERE scope = $1 AND user_id = $2`
 
 	_, err := m.DB.ExecContext(ctx, query, scope, userID)
-	return err
+	return contextErr(ctx, err)
+}
+
+// DeleteByHash revokes a single token, returning ErrRecordNotFound if there is
+// no token of scope with that hash.
+func (m TokenModel) DeleteByHash(ctx context.Context, scope string, hash []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	query := `
+        DELETE FROM tokens
+        WHERE scope = $1 AND hash = $2`
+
+	result, err := m.DB.ExecContext(ctx, query, scope, hash)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
 }
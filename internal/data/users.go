@@ -2,12 +2,12 @@ package data
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql"
 	"errors"
 	"greenlight/internal/validator"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -24,6 +24,14 @@ type User struct {
 	Password  password  `json:"-"`
 	Activated bool      `json:"activated"`
 	Version   int       `json:"-"`
+
+	// PendingEmail is the address the user asked to change to, which only
+	// replaces Email once it has been confirmed with a ScopeEmailChange token.
+	PendingEmail string `json:"pending_email,omitempty"`
+
+	// TokenLastUsedAt is set by GetForToken to when the token the user was
+	// looked up by was last used, so callers can skip redundant touches.
+	TokenLastUsedAt *time.Time `json:"-"`
 }
 
 type password struct {
@@ -32,7 +40,8 @@ type password struct {
 }
 
 type UserModel struct {
-	DB *sql.DB
+	DB       dbtx
+	Timeouts Timeouts
 }
 
 func (p *password) Set(plaintextPassword string) error {
@@ -86,8 +95,8 @@ func ValidateUser(v *validator.Validator, user *User) {
 	}
 }
 
-func (m UserModel) Insert(user *User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m UserModel) Insert(ctx context.Context, user *User) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	query := `
@@ -102,19 +111,52 @@ func (m UserModel) Insert(user *User) error {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
 			return ErrDuplicateEmail
 		default:
-			return err
+			return contextErr(ctx, err)
 		}
 	}
 
 	return nil
 }
 
-func (m UserModel) GetByEmail(email string) (*User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m UserModel) Get(ctx context.Context, id int64) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
 	defer cancel()
 
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated, version
+        SELECT id, created_at, name, email, password_hash, activated, version, coalesce(pending_email, '')
+        FROM users
+        WHERE id = $1`
+
+	var user User
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+		&user.PendingEmail,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, contextErr(ctx, err)
+		}
+	}
+
+	return &user, nil
+}
+
+func (m UserModel) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
+	defer cancel()
+
+	query := `
+        SELECT id, created_at, name, email, password_hash, activated, version, coalesce(pending_email, '')
         FROM users
         WHERE email = $1`
 
@@ -128,27 +170,28 @@ func (m UserModel) GetByEmail(email string) (*User, error) {
 		&user.Password.hash,
 		&user.Activated,
 		&user.Version,
+		&user.PendingEmail,
 	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrRecordNotFound
 		default:
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 	}
 
 	return &user, nil
 }
 
-func (m UserModel) Update(user *User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m UserModel) Update(ctx context.Context, user *User) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
 	defer cancel()
 
 	query := `
         UPDATE users
-        SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
-        WHERE id = $5 AND version = $6
+        SET name = $1, email = $2, password_hash = $3, activated = $4, pending_email = NULLIF($5, ''), version = version + 1
+        WHERE id = $6 AND version = $7
         RETURNING version`
 
 	args := []any{
@@ -156,6 +199,7 @@ func (m UserModel) Update(user *User) error {
 		user.Email,
 		user.Password.hash,
 		user.Activated,
+		user.PendingEmail,
 		user.ID,
 		user.Version,
 	}
@@ -169,21 +213,87 @@ func (m UserModel) Update(user *User) error {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return contextErr(ctx, err)
 		}
 	}
 
 	return nil
 }
 
-func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// Delete removes a user together with their tokens, permissions, ratings,
+// reviews and collections. The rating aggregates of the movies they rated are
+// recalculated in the same transaction.
+func (m UserModel) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Write)
+	defer cancel()
+
+	return inTx(ctx, m.DB, func(db dbtx) error {
+		// The movies are locked before the ratings go, in the same order as
+		// RatingModel takes its locks.
+		query := `
+            SELECT id FROM movies
+            WHERE id IN (SELECT movie_id FROM ratings WHERE user_id = $1)
+            ORDER BY id
+            FOR UPDATE`
+
+		rows, err := db.QueryContext(ctx, query, id)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		defer rows.Close()
+
+		var rated []int64
+		for rows.Next() {
+			var movieID int64
+			err := rows.Scan(&movieID)
+			if err != nil {
+				return contextErr(ctx, err)
+			}
+			rated = append(rated, movieID)
+		}
+		if err = rows.Err(); err != nil {
+			return contextErr(ctx, err)
+		}
+
+		result, err := db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		query = `
+            UPDATE movies
+            SET average_rating = aggregate.average, rating_count = aggregate.count
+            FROM (
+                SELECT movies.id, coalesce(round(avg(ratings.score), 2), 0) AS average, count(ratings.score) AS count
+                FROM movies
+                LEFT JOIN ratings ON ratings.movie_id = movies.id
+                WHERE movies.id = ANY($1)
+                GROUP BY movies.id
+            ) AS aggregate
+            WHERE movies.id = aggregate.id`
+
+		_, err = db.ExecContext(ctx, query, pq.Array(rated))
+		return contextErr(ctx, err)
+	})
+}
+
+func (m UserModel) GetForToken(ctx context.Context, tokenScope, tokenPlaintext string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeouts.Read)
 	defer cancel()
 
 	var user User
 
 	query := `
-        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version, coalesce(users.pending_email, ''), tokens.last_used_at
         FROM users
         INNER JOIN tokens
         ON users.id = tokens.user_id
@@ -191,8 +301,7 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
         AND tokens.scope = $2
         AND tokens.expiry > $3`
 
-	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
-	args := []any{tokenHash[:], tokenScope, time.Now()}
+	args := []any{HashToken(tokenPlaintext), tokenScope, time.Now()}
 
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
@@ -202,13 +311,15 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 		&user.Password.hash,
 		&user.Activated,
 		&user.Version,
+		&user.PendingEmail,
+		&user.TokenLastUsedAt,
 	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrRecordNotFound
 		default:
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 	}
 
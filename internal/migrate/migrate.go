@@ -0,0 +1,383 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// lockID is an arbitrary key for the PostgreSQL advisory lock that stops two
+// processes from migrating the same database at once.
+const lockID = 7_263_405_118
+
+// noTransaction starts a migration that cannot run in a transaction, such as
+// one using CREATE INDEX CONCURRENTLY. Such a migration should hold a single
+// statement, since PostgreSQL runs several sent together in one transaction.
+const noTransaction = "-- migrate:no-transaction"
+
+var (
+	ErrDirty         = errors.New("database is in a dirty migration state, fix it manually and use force")
+	ErrDatabaseAhead = errors.New("database schema is newer than the migrations embedded in this binary")
+	ErrNoChange      = errors.New("no change")
+
+	filenameRX = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+type Status struct {
+	Version    int64
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := filenameRX.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has mismatched names %q and %q", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = version(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return Status{}, err
+	}
+
+	for _, migration := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= status.Version,
+		})
+	}
+
+	return status, nil
+}
+
+// Check reports whether the application can safely run against the current
+// schema: it must not be dirty and must not be newer than the binary.
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("%w (version %d)", ErrDirty, status.Version)
+	case status.Version > m.Latest():
+		return fmt.Errorf("%w (database at %d, binary at %d)", ErrDatabaseAhead, status.Version, m.Latest())
+	}
+
+	return nil
+}
+
+// Up applies at most n pending migrations, or all of them when n <= 0.
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrDirty, current)
+		}
+		if current > m.Latest() {
+			return fmt.Errorf("%w (database at %d, binary at %d)", ErrDatabaseAhead, current, m.Latest())
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if n > 0 && len(applied) == n {
+				break
+			}
+
+			err := run(ctx, conn, migration.up, migration.Version, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return applied, err
+	}
+
+	if len(applied) == 0 {
+		return nil, ErrNoChange
+	}
+
+	return applied, nil
+}
+
+// Down rolls back at most n applied migrations, or all of them when n <= 0.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrDirty, current)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if n > 0 && len(reverted) == n {
+				break
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			err := run(ctx, conn, migration.down, migration.Version, previous)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return reverted, err
+	}
+
+	if len(reverted) == 0 {
+		return nil, ErrNoChange
+	}
+
+	return reverted, nil
+}
+
+// Force records v as the current, clean version without running any SQL.
+// Forcing version 0 marks the database as having no migrations applied.
+func (m *Migrator) Force(ctx context.Context, v int64) error {
+	if v < 0 {
+		return fmt.Errorf("invalid version %d", v)
+	}
+
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		return recordVersion(ctx, conn, v, false)
+	})
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version bigint NOT NULL PRIMARY KEY,
+            dirty boolean NOT NULL
+        )`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func version(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, false, nil
+		default:
+			return 0, false, err
+		}
+	}
+
+	return version, dirty, nil
+}
+
+// run executes the migration body and records the resulting version in one
+// transaction, so a failing migration leaves both schema and version intact.
+// A body that opts out of the transaction is bracketed by marking the
+// migration's version dirty, which a failure leaves in place until forced.
+func run(ctx context.Context, conn *sql.Conn, body string, version, resultingVersion int64) error {
+	if strings.HasPrefix(strings.TrimSpace(body), noTransaction) {
+		err := recordVersion(ctx, conn, version, true)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ExecContext(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		return recordVersion(ctx, conn, resultingVersion, false)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if body != "" {
+		_, err = tx.ExecContext(ctx, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = setVersion(ctx, tx, resultingVersion, false)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// recordVersion sets the recorded version in a transaction of its own.
+func recordVersion(ctx context.Context, conn *sql.Conn, version int64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = setVersion(ctx, tx, version, dirty)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setVersion replaces the recorded version. Version 0 records that no
+// migrations are applied.
+func setVersion(ctx context.Context, tx *sql.Tx, version int64, dirty bool) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+
+	if version > 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
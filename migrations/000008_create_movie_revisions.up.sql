@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS movie_revisions (
+    id bigserial PRIMARY KEY,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    version integer NOT NULL,
+    action text NOT NULL,
+    user_id bigint REFERENCES users ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    title text NOT NULL,
+    year integer NOT NULL,
+    runtime text NOT NULL,
+    genres text[] NOT NULL,
+    UNIQUE (movie_id, version)
+);
+
+INSERT INTO movie_revisions (movie_id, version, action, title, year, runtime, genres)
+SELECT id, version,
+    CASE
+        WHEN deleted_at IS NOT NULL THEN 'delete'
+        WHEN version = 1 THEN 'create'
+        ELSE 'update'
+    END,
+    title, year, runtime, genres
+FROM movies;
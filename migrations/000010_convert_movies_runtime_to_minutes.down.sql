@@ -0,0 +1,5 @@
+ALTER TABLE movie_revisions ALTER COLUMN runtime TYPE text USING runtime || ' mins';
+
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS movies_runtime_check;
+ALTER TABLE movies ALTER COLUMN runtime TYPE text USING runtime || ' mins';
+ALTER TABLE movies ADD CONSTRAINT movies_runtime_check CHECK (LENGTH(runtime) > 0);
@@ -0,0 +1,58 @@
+CREATE FUNCTION parse_runtime_minutes(value text) RETURNS integer
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+    s text := lower(btrim(value));
+    m text[];
+BEGIN
+    m := regexp_match(s, '^(\d+)\s*(?:m|min|mins|minute|minutes)?$');
+    IF m IS NOT NULL THEN
+        RETURN m[1]::integer;
+    END IF;
+
+    m := regexp_match(s, '^(\d+)\s*(?:h|hr|hrs|hour|hours)\s*(?:(\d+)\s*(?:m|min|mins|minute|minutes)?)?$');
+    IF m IS NULL THEN
+        m := regexp_match(s, '^(\d+):([0-5]\d)$');
+    END IF;
+    IF m IS NULL AND s <> 'pt' THEN
+        m := regexp_match(s, '^pt(?:(\d+)h)?(?:(\d+)m)?$');
+    END IF;
+
+    IF m IS NULL OR (m[1] IS NOT NULL AND m[2]::integer >= 60) THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN coalesce(m[1]::integer, 0) * 60 + coalesce(m[2]::integer, 0);
+EXCEPTION
+    WHEN numeric_value_out_of_range THEN
+        RETURN NULL;
+END;
+$$;
+
+DO $$
+DECLARE
+    invalid text;
+BEGIN
+    SELECT string_agg(description, E'\n' ORDER BY description)
+    INTO invalid
+    FROM (
+        SELECT format('movies id=%s runtime=%L', id, runtime) AS description, runtime
+        FROM movies
+        UNION ALL
+        SELECT format('movie_revisions movie_id=%s version=%s runtime=%L', movie_id, version, runtime), runtime
+        FROM movie_revisions
+    ) AS runtimes
+    WHERE coalesce(parse_runtime_minutes(runtime), 0) <= 0;
+
+    IF invalid IS NOT NULL THEN
+        RAISE EXCEPTION 'cannot convert these runtime values to minutes, fix them and run the migration again:%', E'\n' || invalid;
+    END IF;
+END;
+$$;
+
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS movies_runtime_check;
+ALTER TABLE movies ALTER COLUMN runtime TYPE integer USING parse_runtime_minutes(runtime);
+ALTER TABLE movies ADD CONSTRAINT movies_runtime_check CHECK (runtime > 0);
+
+ALTER TABLE movie_revisions ALTER COLUMN runtime TYPE integer USING parse_runtime_minutes(runtime);
+
+DROP FUNCTION parse_runtime_minutes(text);
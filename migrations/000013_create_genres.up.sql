@@ -0,0 +1,91 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    slug text NOT NULL UNIQUE,
+    name text NOT NULL,
+    aliases text[] NOT NULL DEFAULT '{}',
+    version integer NOT NULL DEFAULT 1
+);
+
+INSERT INTO genres (slug, name, aliases)
+VALUES
+    ('action', 'Action', '{}'),
+    ('adventure', 'Adventure', '{}'),
+    ('animation', 'Animation', '{animated,cartoon}'),
+    ('biography', 'Biography', '{biopic,biographical}'),
+    ('comedy', 'Comedy', '{comedies}'),
+    ('crime', 'Crime', '{}'),
+    ('documentary', 'Documentary', '{doc,documentaries}'),
+    ('drama', 'Drama', '{dramas}'),
+    ('family', 'Family', '{}'),
+    ('fantasy', 'Fantasy', '{}'),
+    ('history', 'History', '{historical}'),
+    ('horror', 'Horror', '{}'),
+    ('music', 'Music', '{}'),
+    ('musical', 'Musical', '{musicals}'),
+    ('mystery', 'Mystery', '{}'),
+    ('romance', 'Romance', '{romantic}'),
+    ('sci-fi', 'Science Fiction', '{science-fiction,scifi,sf}'),
+    ('sport', 'Sport', '{sports}'),
+    ('thriller', 'Thriller', '{}'),
+    ('war', 'War', '{}'),
+    ('western', 'Western', '{westerns}');
+
+INSERT INTO permissions (code)
+VALUES
+    ('genres:write');
+
+-- Mirrors data.GenreKey: lower case words joined by single hyphens.
+CREATE FUNCTION genre_key(value text) RETURNS text
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT btrim(regexp_replace(lower(value), '[^[:alnum:]]+', '-', 'g'), '-')
+$$;
+
+CREATE TEMPORARY TABLE movie_genres ON COMMIT DROP AS
+SELECT movies.id AS movie_id, value, position, genres.slug
+FROM movies
+CROSS JOIN LATERAL unnest(movies.genres) WITH ORDINALITY AS g(value, position)
+LEFT JOIN genres ON genre_key(g.value) = genres.slug OR genre_key(g.value) = ANY (genres.aliases);
+
+-- Unknown genres are left untouched so that no data is lost; movies using
+-- them fail validation on their next edit until the genre or an alias for it
+-- has been added through the API.
+DO $$
+DECLARE
+    unknown text;
+BEGIN
+    SELECT string_agg(format('%L (%s movies)', value, movies), ', ' ORDER BY value)
+    INTO unknown
+    FROM (
+        SELECT value, count(DISTINCT movie_id) AS movies
+        FROM movie_genres
+        WHERE slug IS NULL
+        GROUP BY value
+    ) AS unmapped;
+
+    IF unknown IS NOT NULL THEN
+        RAISE NOTICE 'these genres are not in the vocabulary and were left unchanged: %', unknown;
+    END IF;
+END;
+$$;
+
+WITH normalized AS (
+    SELECT movie_id, array_agg(genre ORDER BY position) AS genres
+    FROM (
+        SELECT DISTINCT ON (movie_id, coalesce(slug, value)) movie_id, coalesce(slug, value) AS genre, position
+        FROM movie_genres
+        ORDER BY movie_id, coalesce(slug, value), position
+    ) AS deduplicated
+    GROUP BY movie_id
+), updated AS (
+    UPDATE movies
+    SET genres = normalized.genres, version = movies.version + 1
+    FROM normalized
+    WHERE movies.id = normalized.movie_id AND movies.genres <> normalized.genres
+    RETURNING movies.id, movies.version, movies.title, movies.year, movies.runtime, movies.genres
+)
+INSERT INTO movie_revisions (movie_id, version, action, title, year, runtime, genres)
+SELECT id, version, 'update', title, year, runtime, genres
+FROM updated;
+
+DROP FUNCTION genre_key(text);
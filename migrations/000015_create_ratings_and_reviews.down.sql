@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE code = 'reviews:moderate';
+
+DROP TABLE IF EXISTS reviews;
+
+DROP TABLE IF EXISTS ratings;
+
+DROP INDEX IF EXISTS movies_average_rating_idx;
+ALTER TABLE movies DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE movies DROP COLUMN IF EXISTS average_rating;
@@ -0,0 +1,5 @@
+DELETE FROM tokens WHERE scope = 'refresh';
+
+DROP INDEX IF EXISTS tokens_family_id_idx;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,3 @@
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS family_id bigint;
+
+CREATE INDEX IF NOT EXISTS tokens_family_id_idx ON tokens (family_id);
@@ -0,0 +1,13 @@
+-- Every slug and alias maps to exactly one genre. The primary key makes the
+-- uniqueness that GenreModel relies on hold under concurrent writes.
+CREATE TABLE IF NOT EXISTS genre_keys (
+    key text PRIMARY KEY,
+    genre_id bigint NOT NULL REFERENCES genres ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS genre_keys_genre_id_idx ON genre_keys (genre_id);
+
+INSERT INTO genre_keys (key, genre_id)
+SELECT slug, id FROM genres
+UNION ALL
+SELECT unnest(aliases), id FROM genres;